@@ -0,0 +1,56 @@
+package dns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Class uint16
+
+const (
+	ClassIN   Class = 1
+	ClassCS   Class = 2
+	ClassCH   Class = 3
+	ClassHS   Class = 4
+	ClassNONE Class = 254
+	ClassANY  Class = 255
+)
+
+func (c Class) String() string {
+	switch c {
+	case ClassIN:
+		return "IN"
+	case ClassCS:
+		return "CS"
+	case ClassCH:
+		return "CH"
+	case ClassHS:
+		return "HS"
+	case ClassNONE:
+		return "NONE"
+	case ClassANY:
+		return "ANY"
+	default:
+		return fmt.Sprintf("CLASS%d", uint16(c))
+	}
+}
+
+// ParseClass accepts a class mnemonic such as "IN" or the RFC 3597 generic
+// form "CLASS5".
+func ParseClass(s string) (Class, error) {
+	upper := strings.ToUpper(s)
+	for _, c := range []Class{ClassIN, ClassCS, ClassCH, ClassHS, ClassNONE, ClassANY} {
+		if c.String() == upper {
+			return c, nil
+		}
+	}
+
+	if strings.HasPrefix(upper, "CLASS") {
+		if n, err := strconv.ParseUint(upper[5:], 10, 16); err == nil {
+			return Class(n), nil
+		}
+	}
+
+	return 0, fmt.Errorf("ParseClass: unknown class %q", s)
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+
+	dns "github.com/Sannrox/simple-dns"
+)
+
+// udpQuery is one received datagram. Each carries its own copy of the
+// bytes, so workers share nothing but the socket.
+type udpQuery struct {
+	data []byte
+	src  *net.UDPAddr
+}
+
+func main() {
+	workers := flag.Int("workers", 64, "number of queries handled concurrently")
+	queueLimit := flag.Int("queue", 256, "number of queries waiting for a worker before the server is overloaded")
+	overload := flag.String("overload", "servfail", "what to do with UDP queries while overloaded: servfail or drop")
+	tcpIdle := flag.Duration("tcp-idle", 10*time.Second, "how long a TCP connection may sit without a query")
+	tcpQueries := flag.Int("tcp-queries", 100, "number of queries answered per TCP connection before it is closed")
+	tcpConns := flag.Int("tcp-conns", 128, "number of TCP connections served at once; further clients wait to be accepted")
+	var upstreams upstreamList
+	flag.Var(&upstreams, "upstream", "upstream server as IP or IP:port, [IPv6]:port for IPv6; repeat for fallbacks (default 8.8.8.8:53)")
+	resolvConf := flag.String("resolv-conf", "", "resolv.conf style file whose nameserver lines are used as upstreams after -upstream")
+	config := flag.String("config", "", "file of \"flag value\" lines; flags on the command line take precedence")
+	flag.Parse()
+
+	if *config != "" {
+		if err := readConfig(*config); err != nil {
+			fmt.Println("Error reading config", err)
+			os.Exit(2)
+		}
+	}
+
+	if *resolvConf != "" {
+		nameservers, err := readResolvConf(*resolvConf)
+		if err != nil {
+			fmt.Println("Error reading resolv.conf", err)
+			os.Exit(2)
+		}
+		upstreams = append(upstreams, nameservers...)
+	}
+	if len(upstreams) == 0 {
+		upstreams = upstreamList{defaultUpstream}
+	}
+
+	if *workers < 1 || *queueLimit < 0 || (*overload != "servfail" && *overload != "drop") || *tcpIdle <= 0 || *tcpQueries < 1 || *tcpConns < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	receivServer := "0.0.0.0:2053"
+	localUDPAddr, err := net.ResolveUDPAddr("udp", receivServer)
+	if err != nil {
+		fmt.Println("Error resolving UDP address on ", receivServer)
+		os.Exit(1)
+	}
+	receivConn, err := net.ListenUDP("udp", localUDPAddr)
+	if err != nil {
+		fmt.Println("Error listening on UDP port ", localUDPAddr)
+		os.Exit(1)
+	}
+
+	defer receivConn.Close()
+
+	tcpListener, err := net.Listen("tcp", receivServer)
+	if err != nil {
+		fmt.Println("Error listening on TCP port ", receivServer)
+		os.Exit(1)
+	}
+
+	defer tcpListener.Close()
+
+	// Both transports feed the same pool. A job owns everything it
+	// touches apart from the connection it answers on.
+	queue := make(chan func(), *queueLimit)
+	for i := 0; i < *workers; i++ {
+		go func() {
+			for job := range queue {
+				job()
+			}
+		}()
+	}
+
+	go serveTCP(tcpListener, queue, upstreams, *tcpIdle, *tcpQueries, *tcpConns)
+
+	fmt.Println("UDP and TCP server up and listening on port ", localUDPAddr.Port)
+	fmt.Println("Forwarding to", upstreams.String())
+	serveUDP(receivConn, queue, upstreams, *overload == "drop")
+}
+
+// serveUDP reads queries from conn and hands them to queue until conn is
+// closed. While queue is full a query is answered with SERVFAIL, or
+// silently dropped if dropOnOverload is set.
+func serveUDP(conn *net.UDPConn, queue chan<- func(), upstreams []netip.AddrPort, dropOnOverload bool) {
+	for {
+		reqData := make([]byte, dns.MaxEDNSPacketSize)
+		n, src, err := conn.ReadFromUDP(reqData)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Error reading from socket", err)
+			continue
+		}
+		query := udpQuery{reqData[:n], src}
+
+		select {
+		case queue <- func() { reply(conn, query.src, answer(upstreams, query.data, false)) }:
+		default:
+			if dropOnOverload {
+				continue
+			}
+			reply(conn, query.src, errorResponse(query.data, dns.SERVFAIL))
+		}
+	}
+}
+
+// lookupTimeout bounds how long a worker waits for the upstream server.
+const lookupTimeout = 5 * time.Second
+
+// lookup asks each upstream in turn until one answers. TCP clients are
+// resolved over TCP so their answers are not capped at the UDP size.
+func lookup(upstreams []netip.AddrPort, qname dns.DomainName, qtype dns.QueryType, qclass dns.Class, dnssecOK bool, checkingDisabled bool, overTCP bool) (*dns.DNSPacket, error) {
+	packet := dns.NewDNSPacket()
+	packet.Header.ID = uint16(rand.Intn(1 << 16))
+	packet.Header.QuestionCount = 1
+	packet.Header.RecursionDesired = true
+	packet.Header.CheckingDisabled = checkingDisabled
+	packet.Questions = append(packet.Questions, &dns.DNSQuestion{Name: qname, Type: qtype, Class: qclass})
+	packet.Resources = append(packet.Resources, dns.NewOPTRecord(dns.MaxEDNSPacketSize, dnssecOK))
+
+	buffer := dns.NewBytePacketBuffer()
+	if err := packet.Write(buffer); err != nil {
+		return nil, fmt.Errorf("Error writing to buffer %w", err)
+	}
+
+	query, err := buffer.GetRange(0, buffer.Pos())
+	if err != nil {
+		return nil, fmt.Errorf("Error getting range %w", err)
+	}
+
+	err = fmt.Errorf("No upstream servers configured")
+	for _, upstream := range upstreams {
+		var receivPacket *dns.DNSPacket
+		if receivPacket, err = exchange(upstream, query, packet.Header.ID, overTCP); err == nil {
+			return receivPacket, nil
+		}
+		fmt.Println("Error querying upstream", upstream, err)
+	}
+
+	return nil, err
+}
+
+// exchange sends query to upstream and waits for the reply with the
+// matching ID.
+func exchange(upstream netip.AddrPort, query []byte, id uint16, overTCP bool) (*dns.DNSPacket, error) {
+	var data []byte
+	var err error
+	if overTCP {
+		data, err = exchangeTCP(upstream, query)
+	} else {
+		data, err = exchangeUDP(upstream, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	receivBuffer := dns.NewBytePacketBufferFrom(data)
+
+	receivPacket := dns.NewDNSPacket()
+	receivPacket, err = receivPacket.Read(receivBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading from buffer %w", err)
+	}
+	if receivPacket.Header.ID != id {
+		return nil, fmt.Errorf("Reply ID %d does not match query ID %d", receivPacket.Header.ID, id)
+	}
+
+	// A truncated UDP answer is incomplete; RFC 7766 section 5 says to
+	// retry over TCP. If that fails the truncated answer is passed on with
+	// TC still set, so the client can retry itself.
+	if receivPacket.Header.TruncatedMessage && !overTCP {
+		tcpPacket, err := exchange(upstream, query, id, true)
+		if err == nil {
+			return tcpPacket, nil
+		}
+		fmt.Println("Error retrying truncated answer over TCP", upstream, err)
+	}
+
+	return receivPacket, nil
+}
+
+// exchangeUDP sends query to upstream over UDP and returns the raw reply.
+func exchangeUDP(upstream netip.AddrPort, query []byte) ([]byte, error) {
+	// A connected socket only accepts datagrams from upstream.
+	receivConn, err := net.DialUDP("udp", nil, net.UDPAddrFromAddrPort(upstream))
+	if err != nil {
+		return nil, fmt.Errorf("Error dialing UDP address %w", err)
+	}
+
+	defer receivConn.Close()
+
+	// A lost reply must not hold a worker forever.
+	if err := receivConn.SetDeadline(time.Now().Add(lookupTimeout)); err != nil {
+		return nil, fmt.Errorf("Error setting deadline %w", err)
+	}
+
+	if _, err := receivConn.Write(query); err != nil {
+		return nil, fmt.Errorf("Error writing to socket %w", err)
+	}
+
+	data := make([]byte, dns.MaxEDNSPacketSize)
+	n, err := receivConn.Read(data)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading from socket %w", err)
+	}
+
+	return data[:n], nil
+}
+
+// answer returns the response to send for reqData, or nil if there is
+// none to send.
+func answer(upstreams []netip.AddrPort, reqData []byte, overTCP bool) []byte {
+	resp, err := handleQuery(upstreams, reqData, overTCP)
+	if err != nil {
+		fmt.Println("Error handling query", err)
+
+		var formatErr *dns.FormatError
+		if errors.As(err, &formatErr) {
+			return errorResponse(reqData, dns.FORMERR)
+		}
+		return errorResponse(reqData, dns.SERVFAIL)
+	}
+
+	return resp
+}
+
+// handleQuery resolves the query in reqData and returns the encoded
+// response. Over TCP the response may use the whole 64 KiB frame.
+func handleQuery(upstreams []netip.AddrPort, reqData []byte, overTCP bool) ([]byte, error) {
+	reqBuffer := dns.NewBytePacketBufferFrom(reqData)
+	reqBuffer.SetStrict(true)
+
+	reqPacket := dns.NewDNSPacket()
+	reqPacket, err := reqPacket.Read(reqBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading from buffer %w", err)
+	}
+
+	reqOPT, hasOPT := reqPacket.GetOPT()
+
+	respPacket := dns.NewDNSPacket()
+	respPacket.Header.ID = reqPacket.Header.ID
+	respPacket.Header.RecursionDesired = true
+	respPacket.Header.RecursionAvailable = true
+	respPacket.Header.Response = true
+	respPacket.Header.Opcode = reqPacket.Header.Opcode
+	respPacket.Header.CheckingDisabled = reqPacket.Header.CheckingDisabled
+
+	rcode := dns.NOERROR
+	if reqPacket.Header.Opcode != dns.QUERY {
+		fmt.Printf("Unsupported opcode: %s\n", reqPacket.Header.Opcode)
+		respPacket.Questions = reqPacket.Questions
+		rcode = dns.NOTIMP
+	} else if hasOPT && reqOPT.Version > 0 {
+		// RFC 6891 section 6.1.3: we only speak EDNS version 0.
+		respPacket.Questions = reqPacket.Questions
+		rcode = dns.BADVERS
+	} else if len(reqPacket.Questions) > 0 {
+
+		for _, q := range reqPacket.Questions {
+			fmt.Printf("Received Query: %s\n", q.String())
+			if q.Class != dns.ClassIN {
+				respPacket.Questions = append(respPacket.Questions, q)
+				rcode = dns.NOTIMP
+			} else if packet, err := lookup(upstreams, q.Name, q.Type, q.Class, hasOPT && reqOPT.DO, reqPacket.Header.CheckingDisabled, overTCP); err != nil {
+				rcode = dns.SERVFAIL
+			} else {
+				respPacket.Questions = append(respPacket.Questions, q)
+				rcode = packet.RCode()
+				respPacket.Header.TruncatedMessage = packet.Header.TruncatedMessage
+				// RFC 6840 section 5.8: only pass AD on to clients that
+				// asked for it with DO or AD.
+				respPacket.Header.AuthedData = packet.Header.AuthedData && (reqPacket.Header.AuthedData || hasOPT && reqOPT.DO)
+
+				if err := packet.SynthesizeDNAME(q.Name); errors.Is(err, dns.ErrNameTooLong) {
+					fmt.Println("Error synthesizing CNAME", err)
+					rcode = dns.YXDOMAIN
+				} else if err != nil {
+					return nil, fmt.Errorf("Error synthesizing CNAME %w", err)
+				}
+
+				for _, answer := range packet.Answers {
+					fmt.Printf("Answer: %s\n", answer.String())
+					respPacket.Answers = append(respPacket.Answers, answer)
+				}
+
+				for _, auth := range packet.Authorities {
+					fmt.Printf("Authority: %s\n", auth.String())
+					respPacket.Authorities = append(respPacket.Authorities, auth)
+				}
+
+				for _, resource := range packet.Resources {
+					// OPT is hop-by-hop; we add our own below.
+					if _, ok := resource.(dns.OPTRecord); ok {
+						continue
+					}
+					fmt.Printf("Resource: %s\n", resource.String())
+					respPacket.Resources = append(respPacket.Resources, resource)
+				}
+			}
+		}
+	} else {
+		rcode = dns.FORMERR
+	}
+
+	respSize := uint(dns.MaxUDPPacketSize)
+	if hasOPT {
+		respSize = reqOPT.PayloadSize()
+		if respSize > dns.MaxEDNSPacketSize {
+			respSize = dns.MaxEDNSPacketSize
+		}
+		respPacket.Resources = append(respPacket.Resources, dns.NewOPTRecord(dns.MaxEDNSPacketSize, reqOPT.DO))
+	}
+
+	if overTCP {
+		respSize = dns.MaxTCPPacketSize
+	}
+
+	// Without an OPT record an extended RCODE cannot be expressed.
+	if err := respPacket.SetRCode(rcode); err != nil {
+		respPacket.Header.ResCode = dns.SERVFAIL
+	}
+
+	if err := respPacket.Truncate(respSize); err != nil {
+		return nil, fmt.Errorf("Error truncating response %w", err)
+	}
+
+	respBuffer := dns.NewBytePacketBufferSize(respSize)
+	if err := respPacket.Write(respBuffer); err != nil {
+		return nil, fmt.Errorf("Error writing to buffer %w", err)
+	}
+
+	len := respBuffer.Pos()
+	data, err := respBuffer.GetRange(0, len)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting range %w", err)
+	}
+
+	return data, nil
+}
+
+// errorResponse builds a bare response carrying rcode for the query in
+// reqData. Without at least a full header there is no ID to echo, so it
+// returns nil.
+func errorResponse(reqData []byte, rcode dns.ResultCode) []byte {
+	reqHeader := dns.NewDNSHeader()
+	if err := reqHeader.Read(dns.NewBytePacketBufferFrom(reqData)); err != nil {
+		return nil
+	}
+
+	respPacket := dns.NewDNSPacket()
+	respPacket.Header.ID = reqHeader.ID
+	respPacket.Header.Opcode = reqHeader.Opcode
+	respPacket.Header.RecursionDesired = reqHeader.RecursionDesired
+	respPacket.Header.Response = true
+	respPacket.Header.ResCode = rcode
+
+	respBuffer := dns.NewBytePacketBuffer()
+	if err := respPacket.Write(respBuffer); err != nil {
+		return nil
+	}
+
+	data, err := respBuffer.GetRange(0, respBuffer.Pos())
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// reply sends data to src; a nil response is silently dropped.
+func reply(conn *net.UDPConn, src *net.UDPAddr, data []byte) {
+	if data == nil {
+		return
+	}
+
+	if _, err := conn.WriteToUDP(data, src); err != nil {
+		fmt.Println("Error writing to socket", err)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	dns "github.com/Sannrox/simple-dns"
+)
+
+// encode writes packet into a buffer big enough for any TCP message.
+func encode(t *testing.T, packet *dns.DNSPacket) []byte {
+	t.Helper()
+
+	buffer := dns.NewBytePacketBufferSize(dns.MaxTCPPacketSize)
+	if err := packet.Write(buffer); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	data, err := buffer.GetRange(0, buffer.Pos())
+	if err != nil {
+		t.Fatalf("GetRange: %v", err)
+	}
+	return data
+}
+
+// decode reads a message the server sent back.
+func decode(t *testing.T, data []byte) *dns.DNSPacket {
+	t.Helper()
+
+	packet, err := dns.NewDNSPacket().Read(dns.NewBytePacketBufferFrom(data))
+	if err != nil {
+		t.Fatalf("Read(%x): %v", data, err)
+	}
+	return packet
+}
+
+// newQuery builds a client query for name.
+func newQuery(t *testing.T, id uint16, name dns.DomainName, qtype dns.QueryType) []byte {
+	t.Helper()
+
+	packet := dns.NewDNSPacket()
+	packet.Header.ID = id
+	packet.Header.RecursionDesired = true
+	packet.Questions = append(packet.Questions, dns.NewDNSQuestion(name, qtype))
+	return encode(t, packet)
+}
+
+// replyTo starts the upstream's reply to query.
+func replyTo(query *dns.DNSPacket) *dns.DNSPacket {
+	packet := dns.NewDNSPacket()
+	packet.Header.ID = query.Header.ID
+	packet.Header.Response = true
+	packet.Header.RecursionAvailable = true
+	packet.Questions = query.Questions
+	return packet
+}
+
+// startUpstream runs a stub upstream on a loopback UDP socket and a TCP
+// listener on the same port until the test ends. respond builds the reply
+// to each query and is told which transport it came in on; a nil reply is
+// not sent, and over TCP closes the connection.
+func startUpstream(t *testing.T, respond func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket) netip.AddrPort {
+	t.Helper()
+
+	var udpConn *net.UDPConn
+	var tcpListener net.Listener
+	for attempt := 0; tcpListener == nil; attempt++ {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatalf("ListenUDP: %v", err)
+		}
+		listener, err := net.Listen("tcp", conn.LocalAddr().String())
+		if err != nil {
+			conn.Close()
+			if attempt == 10 {
+				t.Fatalf("Listen: %v", err)
+			}
+			continue
+		}
+		udpConn, tcpListener = conn, listener
+	}
+	t.Cleanup(func() {
+		udpConn.Close()
+		tcpListener.Close()
+	})
+
+	handle := func(data []byte, overTCP bool) []byte {
+		query, err := dns.NewDNSPacket().Read(dns.NewBytePacketBufferFrom(data))
+		if err != nil {
+			return nil
+		}
+		reply := respond(query, overTCP)
+		if reply == nil {
+			return nil
+		}
+		return encode(t, reply)
+	}
+
+	go func() {
+		data := make([]byte, dns.MaxEDNSPacketSize)
+		for {
+			n, src, err := udpConn.ReadFromUDP(data)
+			if err != nil {
+				return
+			}
+			if reply := handle(data[:n], false); reply != nil {
+				udpConn.WriteToUDP(reply, src)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcpListener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					data, err := readFrame(conn)
+					if err != nil {
+						return
+					}
+					reply := handle(data, true)
+					if reply == nil {
+						return
+					}
+					if err := writeFrame(conn, reply); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return netip.MustParseAddrPort(udpConn.LocalAddr().String())
+}
+
+func TestHandleQueryDNAMEOverflowIsYXDOMAIN(t *testing.T) {
+	// 255 octets on the wire: three 63-octet labels and a 61-octet one.
+	long := dns.DomainName(strings.Repeat(strings.Repeat("a", 63)+".", 3) + strings.Repeat("b", 61))
+
+	upstream := startUpstream(t, func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket {
+		reply := replyTo(query)
+		reply.Answers = append(reply.Answers, dns.DNAMERecord{Domain: "example.com", Class: dns.ClassIN, Target: long, TTL: 60})
+		return reply
+	})
+
+	resp, err := handleQuery([]netip.AddrPort{upstream}, newQuery(t, 0x1234, "x.example.com", dns.A), false)
+	if err != nil {
+		t.Fatalf("handleQuery: %v", err)
+	}
+	packet := decode(t, resp)
+	if packet.Header.ID != 0x1234 || packet.RCode() != dns.YXDOMAIN {
+		t.Errorf("response ID %#04x, RCODE %s; want 0x1234, %s", packet.Header.ID, packet.RCode(), dns.YXDOMAIN)
+	}
+}
+
+func TestServeUDP(t *testing.T) {
+	upstream := startUpstream(t, func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket {
+		reply := replyTo(query)
+		reply.Answers = append(reply.Answers, dns.ARecord{Domain: query.Questions[0].Name, Class: dns.ClassIN, Addr: net.IP{192, 0, 2, 1}, TTL: 300})
+		return reply
+	})
+
+	tests := []struct {
+		name    string
+		worker  bool
+		drop    bool
+		replied bool
+		rcode   dns.ResultCode
+	}{
+		{name: "answered by a worker", worker: true, replied: true, rcode: dns.NOERROR},
+		{name: "queue full", replied: true, rcode: dns.SERVFAIL},
+		{name: "queue full, dropping", drop: true},
+	}
+
+	for _, tt := range tests {
+		server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatalf("ListenUDP: %v", err)
+		}
+
+		// Without a worker the queue has no room, so every query
+		// overflows.
+		queue := make(chan func())
+		if tt.worker {
+			queue = make(chan func(), 1)
+			go func() {
+				for job := range queue {
+					job()
+				}
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			serveUDP(server, queue, []netip.AddrPort{upstream}, tt.drop)
+			close(done)
+		}()
+
+		client, err := net.DialUDP("udp", nil, server.LocalAddr().(*net.UDPAddr))
+		if err != nil {
+			t.Fatalf("DialUDP: %v", err)
+		}
+		timeout := 5 * time.Second
+		if !tt.replied {
+			timeout = 200 * time.Millisecond
+		}
+		client.SetDeadline(time.Now().Add(timeout))
+		if _, err := client.Write(newQuery(t, 0xABCD, "example.com", dns.A)); err != nil {
+			t.Fatalf("%s: Write: %v", tt.name, err)
+		}
+
+		data := make([]byte, dns.MaxUDPPacketSize)
+		n, err := client.Read(data)
+		switch {
+		case !tt.replied && err == nil:
+			t.Errorf("%s: got a reply, want none", tt.name)
+		case tt.replied && err != nil:
+			t.Errorf("%s: Read: %v", tt.name, err)
+		case tt.replied:
+			packet := decode(t, data[:n])
+			if packet.Header.ID != 0xABCD || packet.RCode() != tt.rcode {
+				t.Errorf("%s: response ID %#04x, RCODE %s; want 0xabcd, %s", tt.name, packet.Header.ID, packet.RCode(), tt.rcode)
+			}
+		}
+
+		client.Close()
+		server.Close()
+		<-done
+		close(queue)
+	}
+}
+
+func TestExchangeRetriesTruncatedAnswersOverTCP(t *testing.T) {
+	// truncating answers over UDP with TC and an empty answer section;
+	// withTCP says whether it also answers over TCP.
+	truncating := func(withTCP bool) netip.AddrPort {
+		return startUpstream(t, func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket {
+			reply := replyTo(query)
+			if !overTCP {
+				reply.Header.TruncatedMessage = true
+				return reply
+			}
+			if !withTCP {
+				return nil
+			}
+			manyAnswers(reply)
+			return reply
+		})
+	}
+
+	tests := []struct {
+		name      string
+		withTCP   bool
+		answers   int
+		truncated bool
+	}{
+		{"full answer over TCP", true, 300, false},
+		{"no TCP upstream", false, 0, true},
+	}
+
+	for _, tt := range tests {
+		upstream := truncating(tt.withTCP)
+
+		packet, err := exchange(upstream, newQuery(t, 0x7777, "example.com", dns.A), 0x7777, false)
+		if err != nil {
+			t.Errorf("%s: exchange: %v", tt.name, err)
+			continue
+		}
+		if len(packet.Answers) != tt.answers || packet.Header.TruncatedMessage != tt.truncated {
+			t.Errorf("%s: %d answers with TC %v, want %d with TC %v", tt.name, len(packet.Answers), packet.Header.TruncatedMessage, tt.answers, tt.truncated)
+		}
+
+		// A UDP client always gets TC on its cut-down answer. A TCP client
+		// is resolved over TCP, so it gets the whole answer, or SERVFAIL
+		// if the upstream has no TCP.
+		for _, overTCP := range []bool{false, true} {
+			resp, err := handleQuery([]netip.AddrPort{upstream}, newQuery(t, 0x8888, "example.com", dns.A), overTCP)
+			if err != nil {
+				t.Errorf("%s: handleQuery over TCP %v: %v", tt.name, overTCP, err)
+				continue
+			}
+			packet := decode(t, resp)
+
+			switch {
+			case !overTCP:
+				if !packet.Header.TruncatedMessage || len(resp) > dns.MaxUDPPacketSize {
+					t.Errorf("%s: UDP response of %d bytes has TC %v, want TC within %d bytes", tt.name, len(resp), packet.Header.TruncatedMessage, dns.MaxUDPPacketSize)
+				}
+			case tt.withTCP:
+				if packet.Header.TruncatedMessage || len(packet.Answers) != tt.answers {
+					t.Errorf("%s: TCP response has %d answers with TC %v, want %d without TC", tt.name, len(packet.Answers), packet.Header.TruncatedMessage, tt.answers)
+				}
+			default:
+				if packet.RCode() != dns.SERVFAIL {
+					t.Errorf("%s: TCP response RCODE %s, want %s", tt.name, packet.RCode(), dns.SERVFAIL)
+				}
+			}
+		}
+	}
+}
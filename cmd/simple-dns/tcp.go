@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	dns "github.com/Sannrox/simple-dns"
+)
+
+// tcpWriteTimeout bounds how long a slow client can hold up a response.
+const tcpWriteTimeout = 10 * time.Second
+
+// serveTCP accepts connections on listener and hands every query read from
+// them to queue. At most maxConns connections are served at once; further
+// clients wait in the listen backlog until a slot frees up.
+func serveTCP(listener net.Listener, queue chan<- func(), upstreams []netip.AddrPort, idle time.Duration, maxQueries int, maxConns int) {
+	conns := make(chan struct{}, maxConns)
+	for {
+		conns <- struct{}{}
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Error accepting TCP connection", err)
+			return
+		}
+
+		go func() {
+			defer func() { <-conns }()
+			serveTCPConn(conn, queue, upstreams, idle, maxQueries)
+		}()
+	}
+}
+
+// serveTCPConn reads length-prefixed queries (RFC 7766 section 8) until the
+// client goes idle, hangs up or reaches maxQueries. Queries are resolved
+// concurrently and each answer is written as soon as it is ready, so
+// pipelined queries may be answered out of order; clients match them by ID.
+// The idle timeout only runs while no query is outstanding (RFC 7766
+// section 6.2.3).
+func serveTCPConn(conn net.Conn, queue chan<- func(), upstreams []netip.AddrPort, idle time.Duration, maxQueries int) {
+	defer conn.Close()
+
+	// writeMu keeps frames from interleaving; pending lets outstanding
+	// answers go out before the connection is closed.
+	var writeMu sync.Mutex
+	var pending sync.WaitGroup
+	defer pending.Wait()
+
+	// outstanding mirrors the count in pending, which a WaitGroup does not
+	// expose, so that the last answer can restart the idle timer.
+	var idleMu sync.Mutex
+	outstanding := 0
+	start := func() error {
+		idleMu.Lock()
+		defer idleMu.Unlock()
+		outstanding++
+		pending.Add(1)
+		return conn.SetReadDeadline(time.Time{})
+	}
+	done := func() {
+		idleMu.Lock()
+		defer idleMu.Unlock()
+		outstanding--
+		if outstanding == 0 {
+			if err := conn.SetReadDeadline(time.Now().Add(idle)); err != nil {
+				fmt.Println("Error setting deadline", err)
+			}
+		}
+		pending.Done()
+	}
+
+	write := func(resp []byte) {
+		if resp == nil {
+			return
+		}
+
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if err := conn.SetWriteDeadline(time.Now().Add(tcpWriteTimeout)); err != nil {
+			fmt.Println("Error setting deadline", err)
+			return
+		}
+		if err := writeFrame(conn, resp); err != nil {
+			fmt.Println("Error writing to TCP connection", err)
+		}
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(idle)); err != nil {
+		fmt.Println("Error setting deadline", err)
+		return
+	}
+
+	for queries := 0; queries < maxQueries; queries++ {
+		reqData, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+
+		if err := start(); err != nil {
+			fmt.Println("Error setting deadline", err)
+			done()
+			return
+		}
+		job := func() {
+			defer done()
+			write(answer(upstreams, reqData, true))
+		}
+
+		select {
+		case queue <- job:
+		default:
+			// A TCP client would wait for a dropped query until it
+			// timed out, so overload is always answered.
+			write(errorResponse(reqData, dns.SERVFAIL))
+			done()
+		}
+	}
+}
+
+// readFrame reads one message with its 2-byte length prefix.
+func readFrame(conn net.Conn) ([]byte, error) {
+	var prefix [2]byte
+	if _, err := io.ReadFull(conn, prefix[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint16(prefix[:]))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// writeFrame writes data prefixed with its length in a single write.
+func writeFrame(conn net.Conn, data []byte) error {
+	if len(data) > dns.MaxTCPPacketSize {
+		return fmt.Errorf("message of %d bytes does not fit a TCP frame", len(data))
+	}
+
+	frame := make([]byte, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	copy(frame[2:], data)
+
+	_, err := conn.Write(frame)
+	return err
+}
+
+// exchangeTCP sends query to upstream over TCP and returns the raw reply,
+// which may use the whole 64 KiB frame.
+func exchangeTCP(upstream netip.AddrPort, query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", upstream.String(), lookupTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("Error dialing TCP address %w", err)
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(lookupTimeout)); err != nil {
+		return nil, fmt.Errorf("Error setting deadline %w", err)
+	}
+
+	if err := writeFrame(conn, query); err != nil {
+		return nil, fmt.Errorf("Error writing to TCP connection %w", err)
+	}
+
+	data, err := readFrame(conn)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading from TCP connection %w", err)
+	}
+
+	return data, nil
+}
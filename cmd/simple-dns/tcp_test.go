@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+
+	dns "github.com/Sannrox/simple-dns"
+)
+
+// manyAnswers fills reply with more A records than fit a UDP message.
+func manyAnswers(reply *dns.DNSPacket) {
+	name := reply.Questions[0].Name
+	for i := 0; i < 300; i++ {
+		reply.Answers = append(reply.Answers, dns.ARecord{Domain: name, Class: dns.ClassIN, Addr: net.IP{10, 0, byte(i >> 8), byte(i)}, TTL: 300})
+	}
+}
+
+// startTCPServer runs serveTCP with one worker on a loopback listener until
+// the test ends.
+func startTCPServer(t *testing.T, upstream netip.AddrPort, idle time.Duration, maxConns int) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	queue := make(chan func(), 16)
+	t.Cleanup(func() {
+		listener.Close()
+		close(queue)
+	})
+
+	go func() {
+		for job := range queue {
+			job()
+		}
+	}()
+	go serveTCP(listener, queue, []netip.AddrPort{upstream}, idle, 100, maxConns)
+
+	return listener.Addr().String()
+}
+
+// dialTCP connects a client that gives up after five seconds.
+func dialTCP(t *testing.T, addr string) net.Conn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+// ask sends one framed query on conn and returns the response.
+func ask(t *testing.T, conn net.Conn, id uint16) (*dns.DNSPacket, error) {
+	t.Helper()
+
+	if err := writeFrame(conn, newQuery(t, id, "example.com", dns.A)); err != nil {
+		return nil, err
+	}
+	data, err := readFrame(conn)
+	if err != nil {
+		return nil, err
+	}
+	return decode(t, data), nil
+}
+
+func TestExchangeTCP(t *testing.T) {
+	upstream := startUpstream(t, func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket {
+		if !overTCP {
+			return nil
+		}
+		reply := replyTo(query)
+		manyAnswers(reply)
+		return reply
+	})
+
+	data, err := exchangeTCP(upstream, newQuery(t, 0x4242, "example.com", dns.A))
+	if err != nil {
+		t.Fatalf("exchangeTCP: %v", err)
+	}
+	if len(data) <= dns.MaxEDNSPacketSize {
+		t.Errorf("reply is %d bytes, want more than a UDP message holds", len(data))
+	}
+	if packet := decode(t, data); packet.Header.ID != 0x4242 || len(packet.Answers) != 300 {
+		t.Errorf("reply ID %#04x with %d answers, want 0x4242 with 300", packet.Header.ID, len(packet.Answers))
+	}
+
+	silent := startUpstream(t, func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket { return nil })
+	if _, err := exchangeTCP(silent, newQuery(t, 0x4242, "example.com", dns.A)); err == nil {
+		t.Errorf("exchangeTCP with an upstream that hangs up: got no error")
+	}
+}
+
+func TestServeTCPIdleTimeoutWaitsForAnswers(t *testing.T) {
+	const idle = 100 * time.Millisecond
+	upstream := startUpstream(t, func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket {
+		// Resolving takes longer than the idle timeout.
+		time.Sleep(3 * idle)
+		return replyTo(query)
+	})
+	conn := dialTCP(t, startTCPServer(t, upstream, idle, 1))
+
+	// The second query only gets through if the slow first one kept the
+	// connection from going idle.
+	for id := uint16(1); id <= 2; id++ {
+		packet, err := ask(t, conn, id)
+		if err != nil {
+			t.Fatalf("query %d: %v", id, err)
+		}
+		if packet.Header.ID != id || packet.RCode() != dns.NOERROR {
+			t.Errorf("query %d: response ID %d, RCODE %s", id, packet.Header.ID, packet.RCode())
+		}
+	}
+
+	// With nothing outstanding the idle timeout closes the connection.
+	start := time.Now()
+	if _, err := readFrame(conn); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("read from an idle connection: got %v, want the server to hang up", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("idle connection closed after %v, want about %v", elapsed, idle)
+	}
+}
+
+func TestServeTCPConnectionLimit(t *testing.T) {
+	upstream := startUpstream(t, func(query *dns.DNSPacket, overTCP bool) *dns.DNSPacket {
+		return replyTo(query)
+	})
+	addr := startTCPServer(t, upstream, 5*time.Second, 1)
+
+	first := dialTCP(t, addr)
+	if _, err := ask(t, first, 1); err != nil {
+		t.Fatalf("first connection: %v", err)
+	}
+
+	// The second client is left in the backlog while the first holds the
+	// only slot.
+	second := dialTCP(t, addr)
+	if err := writeFrame(second, newQuery(t, 2, "example.com", dns.A)); err != nil {
+		t.Fatalf("second connection: %v", err)
+	}
+	second.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, err := readFrame(second); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("second connection while the first is open: got %v, want no answer", err)
+	}
+
+	first.Close()
+	second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := readFrame(second)
+	if err != nil {
+		t.Fatalf("second connection after the first closed: %v", err)
+	}
+	if packet := decode(t, data); packet.Header.ID != 2 {
+		t.Errorf("second connection: response ID %d, want 2", packet.Header.ID)
+	}
+}
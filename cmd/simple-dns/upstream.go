@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// defaultUpstream is used when no upstream is configured.
+var defaultUpstream = netip.MustParseAddrPort("8.8.8.8:53")
+
+// upstreamList collects the repeatable -upstream flag.
+type upstreamList []netip.AddrPort
+
+func (l *upstreamList) String() string {
+	if l == nil {
+		return ""
+	}
+	addrs := make([]string, len(*l))
+	for i, upstream := range *l {
+		addrs[i] = upstream.String()
+	}
+	return strings.Join(addrs, ",")
+}
+
+func (l *upstreamList) Set(s string) error {
+	upstream, err := parseUpstream(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, upstream)
+	return nil
+}
+
+// parseUpstream accepts "192.0.2.1", "192.0.2.1:5353", "2001:db8::1" and
+// "[2001:db8::1]:5353". The port defaults to 53.
+func parseUpstream(s string) (netip.AddrPort, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.AddrPortFrom(addr, 53), nil
+	}
+	upstream, err := netip.ParseAddrPort(s)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid upstream %q: want an IP address with an optional port", s)
+	}
+	return upstream, nil
+}
+
+// readResolvConf returns the nameservers listed in a resolv.conf(5) style
+// file. Every other directive is ignored.
+func readResolvConf(path string) ([]netip.AddrPort, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	upstreams := make([]netip.AddrPort, 0)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		upstream, err := parseUpstream(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return upstreams, nil
+}
+
+// readConfig applies a config file of "flag value" lines, one flag per line,
+// e.g. "upstream [2001:db8::1]:53". Lines starting with # or ; are comments.
+// Flags given on the command line win over the file.
+func readConfig(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fromCommandLine := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		fromCommandLine[f.Name] = true
+	})
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		name := fields[0]
+		if name == "config" || flag.Lookup(name) == nil {
+			return fmt.Errorf("%s:%d: unknown setting %q", path, line, name)
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("%s:%d: want \"%s value\"", path, line, name)
+		}
+		if fromCommandLine[name] {
+			continue
+		}
+		if err := flag.Set(name, fields[1]); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to a file in a fresh temporary directory.
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestParseUpstream(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"192.0.2.1", "192.0.2.1:53"},
+		{"192.0.2.1:5353", "192.0.2.1:5353"},
+		{"2001:db8::1", "[2001:db8::1]:53"},
+		{"[2001:db8::1]:5353", "[2001:db8::1]:5353"},
+		// Without brackets a trailing ":53" is part of the address.
+		{"2001:db8::1:53", "[2001:db8::1:53]:53"},
+	}
+	for _, tt := range tests {
+		got, err := parseUpstream(tt.input)
+		if err != nil || got != netip.MustParseAddrPort(tt.want) {
+			t.Errorf("parseUpstream(%q) = %v, %v, want %s", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{
+		"",
+		"dns.example.com",
+		"192.0.2.1:",
+		"192.0.2.1:dns",
+		"192.0.2.1:65536",
+		"192.0.2.1:-1",
+		"[2001:db8::1]",
+		"[2001:db8::1]:",
+		"2001:db8::1]:53",
+	} {
+		if got, err := parseUpstream(input); err == nil {
+			t.Errorf("parseUpstream(%q) = %v, want an error", input, got)
+		}
+	}
+}
+
+func TestReadResolvConf(t *testing.T) {
+	path := writeFile(t, "resolv.conf", `# generated by a DHCP client
+; another comment
+search example.com
+nameserver 192.0.2.53
+options ndots:2 timeout:1
+nameserver   2001:db8::53
+nameserver
+# nameserver 192.0.2.99
+`)
+
+	got, err := readResolvConf(path)
+	if err != nil {
+		t.Fatalf("readResolvConf: %v", err)
+	}
+	want := []netip.AddrPort{netip.MustParseAddrPort("192.0.2.53:53"), netip.MustParseAddrPort("[2001:db8::53]:53")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readResolvConf = %v, want %v", got, want)
+	}
+
+	path = writeFile(t, "resolv.conf", "nameserver 192.0.2.53\nnameserver ns.example.com\n")
+	if _, err := readResolvConf(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("readResolvConf with a bad nameserver on line 2: got %v", err)
+	}
+
+	if _, err := readResolvConf(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("readResolvConf of a missing file: got no error")
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	// newFlags installs a fresh command line, as main defines it, for the
+	// duration of one case.
+	newFlags := func(args ...string) (workers, queue *int, upstreams *upstreamList) {
+		saved := flag.CommandLine
+		t.Cleanup(func() { flag.CommandLine = saved })
+
+		flag.CommandLine = flag.NewFlagSet("simple-dns", flag.ContinueOnError)
+		workers = flag.Int("workers", 64, "")
+		queue = flag.Int("queue", 256, "")
+		upstreams = &upstreamList{}
+		flag.Var(upstreams, "upstream", "")
+		flag.String("config", "", "")
+		if err := flag.CommandLine.Parse(args); err != nil {
+			t.Fatalf("Parse(%q): %v", args, err)
+		}
+		return workers, queue, upstreams
+	}
+
+	workers, queue, upstreams := newFlags("-workers", "8")
+	path := writeFile(t, "simple-dns.conf", `# worker pool
+workers 16
+; queue length
+queue 32
+
+  upstream 192.0.2.1
+upstream [2001:db8::1]:5353
+`)
+	if err := readConfig(path); err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	if *workers != 8 {
+		t.Errorf("workers = %d, want the command line's 8", *workers)
+	}
+	if *queue != 32 {
+		t.Errorf("queue = %d, want 32", *queue)
+	}
+	want := upstreamList{netip.MustParseAddrPort("192.0.2.1:53"), netip.MustParseAddrPort("[2001:db8::1]:5353")}
+	if !reflect.DeepEqual(*upstreams, want) {
+		t.Errorf("upstreams = %v, want %v", *upstreams, want)
+	}
+
+	for _, content := range []string{
+		"resolver 192.0.2.1\n",          // unknown setting
+		"config other.conf\n",           // no nested config files
+		"workers\n",                     // missing value
+		"workers 8 16\n",                // too many values
+		"workers many\n",                // invalid value
+		"upstream dns.example.com:53\n", // invalid upstream
+	} {
+		newFlags()
+		if err := readConfig(writeFile(t, "simple-dns.conf", content)); err == nil {
+			t.Errorf("readConfig(%q): got no error", content)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package dns
+
+import (
+	"fmt"
+)
+
+// maxCNAMEChain bounds how many CNAME/DNAME hops SynthesizeDNAME follows.
+const maxCNAMEChain = 16
+
+// synthesizeCNAME builds the CNAME that dname implies for qname, as
+// described in RFC 6672 section 3.
+func synthesizeCNAME(dname DNAMERecord, qname DomainName) (CNameRecord, error) {
+	labels, err := qname.Labels()
+	if err != nil {
+		return CNameRecord{}, fmt.Errorf("synthesizeCNAME: %w", err)
+	}
+	target, err := dname.Target.Labels()
+	if err != nil {
+		return CNameRecord{}, fmt.Errorf("synthesizeCNAME: %w", err)
+	}
+
+	prefix := labels[:len(labels)-dname.Domain.CountLabels()]
+	host := DomainNameFromLabels(append(append([]string(nil), prefix...), target...))
+	if host.WireLength() > maxNameLength {
+		return CNameRecord{}, fmt.Errorf("synthesizeCNAME: %s: %w", host, ErrNameTooLong)
+	}
+
+	return CNameRecord{DomainNameFromLabels(labels), dname.Class, host, dname.TTL}, nil
+}
+
+// SynthesizeDNAME walks the answer chain for qname and inserts the CNAME
+// implied by each DNAME that covers a name in the chain, unless the answer
+// already carries it. An error wrapping ErrNameTooLong means a substituted
+// name grew too long and the response should be YXDOMAIN (RFC 6672 section
+// 2.2); any other error means a name in the answer could not be parsed.
+func (d *DNSPacket) SynthesizeDNAME(qname DomainName) error {
+	current := qname
+
+	for hops := 0; hops < maxCNAMEChain; hops++ {
+		var next DomainName
+		found := false
+
+		for _, answer := range d.Answers {
+			if cname, ok := answer.(CNameRecord); ok && cname.Domain.Equal(current) {
+				next, found = cname.Host, true
+				break
+			}
+		}
+
+		if !found {
+			for i, answer := range d.Answers {
+				dname, ok := answer.(DNAMERecord)
+				if !ok || !current.IsSubdomainOf(dname.Domain) || current.Equal(dname.Domain) {
+					continue
+				}
+
+				cname, err := synthesizeCNAME(dname, current)
+				if err != nil {
+					return fmt.Errorf("DNSPacket.SynthesizeDNAME: %w", err)
+				}
+
+				answers := make([]DnsRecord, 0, len(d.Answers)+1)
+				answers = append(answers, d.Answers[:i+1]...)
+				answers = append(answers, cname)
+				answers = append(answers, d.Answers[i+1:]...)
+				d.Answers = answers
+
+				next, found = cname.Host, true
+				break
+			}
+		}
+
+		if !found {
+			return nil
+		}
+		current = next
+	}
+
+	return nil
+}
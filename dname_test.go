@@ -0,0 +1,101 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSynthesizeDNAME(t *testing.T) {
+	dname := DNAMERecord{Domain: "example.com", Class: ClassIN, Target: "example.net", TTL: 60}
+	addr := ARecord{Domain: "www.example.net", Class: ClassIN, Addr: net.IP{192, 0, 2, 1}, TTL: 300}
+	cname := CNameRecord{Domain: "www.example.com", Class: ClassIN, Host: "www.example.net", TTL: 60}
+
+	// A chain of two DNAMEs with a CNAME in between:
+	// x.a.test -> x.b.test -> y.c.test -> y.d.test.
+	hop1 := DNAMERecord{Domain: "a.test", Class: ClassIN, Target: "b.test", TTL: 10}
+	hop2 := CNameRecord{Domain: "x.b.test", Class: ClassIN, Host: "y.c.test", TTL: 20}
+	hop3 := DNAMERecord{Domain: "c.test", Class: ClassIN, Target: "d.test", TTL: 30}
+	end := ARecord{Domain: "y.d.test", Class: ClassIN, Addr: net.IP{192, 0, 2, 2}, TTL: 40}
+
+	tests := []struct {
+		name    string
+		qname   DomainName
+		answers []DnsRecord
+		want    []DnsRecord
+	}{
+		{
+			name:    "CNAME goes right after its DNAME",
+			qname:   "www.example.com",
+			answers: []DnsRecord{dname, addr},
+			want:    []DnsRecord{dname, cname, addr},
+		},
+		{
+			name:    "CNAME already present",
+			qname:   "www.example.com",
+			answers: []DnsRecord{dname, cname, addr},
+			want:    []DnsRecord{dname, cname, addr},
+		},
+		{
+			name:    "DNAME owner itself is not substituted",
+			qname:   "example.com",
+			answers: []DnsRecord{dname},
+			want:    []DnsRecord{dname},
+		},
+		{
+			name:    "multi-hop chain",
+			qname:   "x.a.test",
+			answers: []DnsRecord{hop1, hop2, hop3, end},
+			want: []DnsRecord{
+				hop1,
+				CNameRecord{Domain: "x.a.test", Class: ClassIN, Host: "x.b.test", TTL: 10},
+				hop2,
+				hop3,
+				CNameRecord{Domain: "y.c.test", Class: ClassIN, Host: "y.d.test", TTL: 30},
+				end,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		packet := NewDNSPacket()
+		packet.Answers = tt.answers
+		if err := packet.SynthesizeDNAME(tt.qname); err != nil {
+			t.Errorf("%s: SynthesizeDNAME(%q): %v", tt.name, tt.qname, err)
+			continue
+		}
+		if !reflect.DeepEqual(packet.Answers, tt.want) {
+			t.Errorf("%s: answers = %v, want %v", tt.name, packet.Answers, tt.want)
+		}
+	}
+}
+
+func TestSynthesizeDNAMEErrors(t *testing.T) {
+	// 255 octets on the wire: three 63-octet labels and a 61-octet one.
+	long := DomainName(strings.Repeat(strings.Repeat("a", 63)+".", 3) + strings.Repeat("b", 61))
+
+	tests := []struct {
+		name    string
+		qname   DomainName
+		target  DomainName
+		tooLong bool
+	}{
+		{"substitution exceeds 255 octets", "x.y.example.com", long, true},
+		{"unparseable target", "x.example.com", `bad\`, false},
+	}
+
+	for _, tt := range tests {
+		packet := NewDNSPacket()
+		packet.Answers = append(packet.Answers, DNAMERecord{Domain: "example.com", Class: ClassIN, Target: tt.target, TTL: 60})
+
+		err := packet.SynthesizeDNAME(tt.qname)
+		if err == nil {
+			t.Fatalf("%s: got no error", tt.name)
+		}
+		if errors.Is(err, ErrNameTooLong) != tt.tooLong {
+			t.Errorf("%s: errors.Is(%v, ErrNameTooLong) = %v, want %v", tt.name, err, !tt.tooLong, tt.tooLong)
+		}
+	}
+}
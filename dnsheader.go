@@ -1,47 +1,50 @@
-package main
+package dns
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type DNSHeader struct {
 	ID uint16
 
-	recursionDesired bool
-	truncatedMessage bool
-	authoritative    bool
-	opcode           uint8
-	response         bool
-
-	rescode            ResultCode
-	checkingDisabled   bool
-	authedData         bool
-	z                  bool
-	recursionAvailable bool
-
-	questionCount        uint16
-	answerCount          uint16
-	authoritativeEntries uint16
-	resourceEntries      uint16
+	RecursionDesired bool
+	TruncatedMessage bool
+	Authoritative    bool
+	Opcode           Opcode
+	Response         bool
+
+	ResCode            ResultCode
+	CheckingDisabled   bool
+	AuthedData         bool
+	Z                  bool
+	RecursionAvailable bool
+
+	QuestionCount        uint16
+	AnswerCount          uint16
+	AuthoritativeEntries uint16
+	ResourceEntries      uint16
 }
 
 func NewDNSHeader() *DNSHeader {
 	return &DNSHeader{
 		ID:               0,
-		recursionDesired: false,
-		truncatedMessage: false,
-		authoritative:    false,
-		opcode:           0,
-		response:         false,
-
-		rescode:            NOERROR,
-		checkingDisabled:   false,
-		authedData:         false,
-		z:                  false,
-		recursionAvailable: false,
-
-		questionCount:        0,
-		answerCount:          0,
-		authoritativeEntries: 0,
-		resourceEntries:      0,
+		RecursionDesired: false,
+		TruncatedMessage: false,
+		Authoritative:    false,
+		Opcode:           QUERY,
+		Response:         false,
+
+		ResCode:            NOERROR,
+		CheckingDisabled:   false,
+		AuthedData:         false,
+		Z:                  false,
+		RecursionAvailable: false,
+
+		QuestionCount:        0,
+		AnswerCount:          0,
+		AuthoritativeEntries: 0,
+		ResourceEntries:      0,
 	}
 }
 
@@ -56,34 +59,21 @@ func (h *DNSHeader) Read(buffer *BytePacketBuffer) error {
 	if err != nil {
 		return err
 	}
-	a := flags >> 8
-	b := flags & 0x00FF
-
-	h.recursionDesired = (a & (1 << 0)) > 0
-	h.truncatedMessage = (a & (1 << 1)) > 0
-	h.authoritative = (a & (1 << 2)) > 0
-	h.opcode = uint8((a >> 3) & 0x0F)
-	h.response = (a & (1 << 7)) > 0
-
-	h.rescode = ResultCode(b & 0x0F)
-	h.checkingDisabled = (b & (1 << 4)) > 0
-	h.authedData = (b & (1 << 5)) > 0
-	h.z = (b & (1 << 6)) > 0
-	h.recursionAvailable = (b & (1 << 7)) > 0
-
-	h.questionCount, err = buffer.ReadU16()
+	h.SetFlags(HeaderFlags(flags))
+
+	h.QuestionCount, err = buffer.ReadU16()
 	if err != nil {
 		return err
 	}
-	h.answerCount, err = buffer.ReadU16()
+	h.AnswerCount, err = buffer.ReadU16()
 	if err != nil {
 		return err
 	}
-	h.authoritativeEntries, err = buffer.ReadU16()
+	h.AuthoritativeEntries, err = buffer.ReadU16()
 	if err != nil {
 		return err
 	}
-	h.resourceEntries, err = buffer.ReadU16()
+	h.ResourceEntries, err = buffer.ReadU16()
 	if err != nil {
 		return err
 	}
@@ -97,79 +87,123 @@ func (h *DNSHeader) Write(bufffer *BytePacketBuffer) error {
 		return err
 	}
 
-	if err := bufffer.WriteU8(h.encodeFlagsA()); err != nil {
-		return err
-	}
-
-	if err := bufffer.WriteU8(uint8(h.rescode)); err != nil {
+	if err := bufffer.WriteU16(uint16(h.Flags())); err != nil {
 		return err
 	}
 
-	if err := bufffer.WriteU16(h.questionCount); err != nil {
+	if err := bufffer.WriteU16(h.QuestionCount); err != nil {
 		return err
 	}
 
-	if err := bufffer.WriteU16(h.answerCount); err != nil {
+	if err := bufffer.WriteU16(h.AnswerCount); err != nil {
 		return err
 	}
 
-	if err := bufffer.WriteU16(h.authoritativeEntries); err != nil {
+	if err := bufffer.WriteU16(h.AuthoritativeEntries); err != nil {
 		return err
 	}
 
-	if err := bufffer.WriteU16(h.resourceEntries); err != nil {
+	if err := bufffer.WriteU16(h.ResourceEntries); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (h *DNSHeader) encodeFlagsA() uint8 {
-	var flags uint8
-
-	if h.recursionDesired {
-		flags |= (1 << 0)
-	}
-
-	if h.truncatedMessage {
-		flags |= (1 << 1)
-	}
-
-	if h.authoritative {
-		flags |= (1 << 2)
-	}
-
-	flags |= (h.opcode << 3)
-
-	if h.response {
-		flags |= (1 << 7)
-	}
-
+// Flags packs the flag fields, Opcode and the low 4 bits of ResCode into
+// the second word of the header.
+func (h *DNSHeader) Flags() HeaderFlags {
+	flags := HeaderFlags(uint16(h.Opcode&0x0F)<<11 | uint16(h.ResCode&0x0F))
+	flags = flags.With(FlagQR, h.Response)
+	flags = flags.With(FlagAA, h.Authoritative)
+	flags = flags.With(FlagTC, h.TruncatedMessage)
+	flags = flags.With(FlagRD, h.RecursionDesired)
+	flags = flags.With(FlagRA, h.RecursionAvailable)
+	flags = flags.With(FlagZ, h.Z)
+	flags = flags.With(FlagAD, h.AuthedData)
+	flags = flags.With(FlagCD, h.CheckingDisabled)
 	return flags
 }
 
-func (h *DNSHeader) encodeFlagsB() uint8 {
-	var flags uint8
+// SetFlags is the inverse of Flags.
+func (h *DNSHeader) SetFlags(flags HeaderFlags) {
+	h.Response = flags.Has(FlagQR)
+	h.Opcode = flags.Opcode()
+	h.Authoritative = flags.Has(FlagAA)
+	h.TruncatedMessage = flags.Has(FlagTC)
+	h.RecursionDesired = flags.Has(FlagRD)
+	h.RecursionAvailable = flags.Has(FlagRA)
+	h.Z = flags.Has(FlagZ)
+	h.AuthedData = flags.Has(FlagAD)
+	h.CheckingDisabled = flags.Has(FlagCD)
+	h.ResCode = flags.RCode()
+}
 
-	if h.checkingDisabled {
-		flags |= (1 << 4)
-	}
+func (h *DNSHeader) String() string {
+	return fmt.Sprintf("ID: %d, OP: %s, RCODE: %s, FLAGS: [%s], QD: %d, AN: %d, NS: %d, AR: %d", h.ID, h.Opcode, h.ResCode, h.Flags(), h.QuestionCount, h.AnswerCount, h.AuthoritativeEntries, h.ResourceEntries)
+}
 
-	if h.authedData {
-		flags |= (1 << 5)
-	}
+// HeaderFlags is the second 16-bit word of the header (RFC 1035 section
+// 4.1.1, with AD and CD from RFC 4035): QR, Opcode, AA, TC, RD, RA, Z, AD,
+// CD and RCODE, from the most significant bit down.
+type HeaderFlags uint16
+
+const (
+	FlagQR HeaderFlags = 1 << 15
+	FlagAA HeaderFlags = 1 << 10
+	FlagTC HeaderFlags = 1 << 9
+	FlagRD HeaderFlags = 1 << 8
+	FlagRA HeaderFlags = 1 << 7
+	FlagZ  HeaderFlags = 1 << 6
+	FlagAD HeaderFlags = 1 << 5
+	FlagCD HeaderFlags = 1 << 4
+)
+
+// headerFlagNames lists the single-bit flags in wire order with the names
+// dig prints for them.
+var headerFlagNames = []struct {
+	flag HeaderFlags
+	name string
+}{
+	{FlagQR, "qr"},
+	{FlagAA, "aa"},
+	{FlagTC, "tc"},
+	{FlagRD, "rd"},
+	{FlagRA, "ra"},
+	{FlagZ, "z"},
+	{FlagAD, "ad"},
+	{FlagCD, "cd"},
+}
 
-	if h.z {
-		flags |= (1 << 6)
-	}
+func (f HeaderFlags) Has(flag HeaderFlags) bool {
+	return f&flag == flag
+}
 
-	if h.recursionAvailable {
-		flags |= (1 << 7)
+// With returns f with flag set or cleared.
+func (f HeaderFlags) With(flag HeaderFlags, set bool) HeaderFlags {
+	if set {
+		return f | flag
 	}
+	return f &^ flag
+}
 
-	return flags
+func (f HeaderFlags) Opcode() Opcode {
+	return Opcode(f >> 11 & 0x0F)
 }
 
-func (h *DNSHeader) String() string {
-	return fmt.Sprintf("ID: %d, RD: %t, TC: %t, AA: %t, OP: %d, R: %t, RCODE: %d, QD: %d, AN: %d, NS: %d, AR: %d", h.ID, h.recursionDesired, h.truncatedMessage, h.authoritative, h.opcode, h.response, h.rescode, h.questionCount, h.answerCount, h.authoritativeEntries, h.resourceEntries)
+// RCode returns the low 4 bits of the RCODE; see DNSPacket.RCode for the
+// extended value.
+func (f HeaderFlags) RCode() ResultCode {
+	return ResultCode(f & 0x0F)
+}
+
+// String lists the single-bit flags that are set, e.g. "qr rd ra".
+func (f HeaderFlags) String() string {
+	names := make([]string, 0, len(headerFlagNames))
+	for _, entry := range headerFlagNames {
+		if f.Has(entry.flag) {
+			names = append(names, entry.name)
+		}
+	}
+	return strings.Join(names, " ")
 }
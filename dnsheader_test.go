@@ -0,0 +1,62 @@
+package dns
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+)
+
+func TestHeaderFlagsRoundTrip(t *testing.T) {
+	for word := 0; word <= 0xFFFF; word++ {
+		flags := HeaderFlags(word)
+
+		header := NewDNSHeader()
+		header.SetFlags(flags)
+		if got := header.Flags(); got != flags {
+			t.Fatalf("SetFlags(%#04x).Flags() = %#04x", uint16(flags), uint16(got))
+		}
+		if got := header.Opcode; got != flags.Opcode() {
+			t.Fatalf("SetFlags(%#04x): Opcode = %s, want %s", uint16(flags), got, flags.Opcode())
+		}
+		if got := header.ResCode; got != flags.RCode() {
+			t.Fatalf("SetFlags(%#04x): ResCode = %s, want %s", uint16(flags), got, flags.RCode())
+		}
+	}
+}
+
+func TestHeaderWireRoundTrip(t *testing.T) {
+	// Any 12 bytes are a valid header, and decoding then encoding them
+	// must give back the same bytes.
+	roundTrip := func(id, flags, qdcount, ancount, nscount, arcount uint16) bool {
+		data := []byte{
+			byte(id >> 8), byte(id),
+			byte(flags >> 8), byte(flags),
+			byte(qdcount >> 8), byte(qdcount),
+			byte(ancount >> 8), byte(ancount),
+			byte(nscount >> 8), byte(nscount),
+			byte(arcount >> 8), byte(arcount),
+		}
+
+		header := NewDNSHeader()
+		if err := header.Read(NewBytePacketBufferFrom(data)); err != nil {
+			t.Logf("Read(%x): %v", data, err)
+			return false
+		}
+
+		buffer := NewBytePacketBuffer()
+		if err := header.Write(buffer); err != nil {
+			t.Logf("Write: %v", err)
+			return false
+		}
+		got, err := buffer.GetRange(0, buffer.Pos())
+		if err != nil {
+			t.Logf("GetRange: %v", err)
+			return false
+		}
+		return bytes.Equal(got, data)
+	}
+
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
+	}
+}
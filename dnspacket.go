@@ -1,10 +1,10 @@
-package main
+package dns
 
 import (
 	"fmt"
 	"math/rand"
 	"net"
-	"strings"
+	"sort"
 )
 
 type DNSPacket struct {
@@ -12,7 +12,7 @@ type DNSPacket struct {
 	Questions   []*DNSQuestion
 	Answers     []DnsRecord
 	Authorities []DnsRecord
-	Reources    []DnsRecord
+	Resources   []DnsRecord
 }
 
 func NewDNSPacket() *DNSPacket {
@@ -21,85 +21,119 @@ func NewDNSPacket() *DNSPacket {
 		Questions:   make([]*DNSQuestion, 0),
 		Answers:     make([]DnsRecord, 0),
 		Authorities: make([]DnsRecord, 0),
-		Reources:    make([]DnsRecord, 0),
+		Resources:   make([]DnsRecord, 0),
 	}
 }
 
+// Minimum wire sizes of a question and a resource record: a root owner name
+// followed by the fixed fields.
+const (
+	minQuestionSize = 1 + 4
+	minRecordSize   = 1 + 10
+)
+
+// Read decodes a whole message. Any failure is returned as a *FormatError.
 func (d *DNSPacket) Read(buffer *BytePacketBuffer) (*DNSPacket, error) {
+	result, err := d.read(buffer)
+	if err != nil {
+		return nil, &FormatError{Offset: buffer.Pos(), Err: err}
+	}
+
+	return result, nil
+}
+
+func (d *DNSPacket) read(buffer *BytePacketBuffer) (*DNSPacket, error) {
 	result := NewDNSPacket()
 	err := result.Header.Read(buffer)
 	if err != nil {
-		return nil, fmt.Errorf("DNSPacket.Read.Header: %s", err)
+		return nil, fmt.Errorf("DNSPacket.Read.Header: %w", err)
 	}
 
-	for i := 0; i < int(result.Header.questionCount); i++ {
+	if buffer.strict {
+		records := uint(result.Header.AnswerCount) + uint(result.Header.AuthoritativeEntries) + uint(result.Header.ResourceEntries)
+		needed := uint(result.Header.QuestionCount)*minQuestionSize + records*minRecordSize
+		if needed > buffer.Len()-buffer.Pos() {
+			return nil, fmt.Errorf("DNSPacket.Read: %w", ErrSectionCount)
+		}
+	}
+
+	for i := 0; i < int(result.Header.QuestionCount); i++ {
 		question := NewDNSQuestion("", UNKNOWN)
 		err := question.Read(buffer)
 		if err != nil {
-			return nil, fmt.Errorf("DNSPacket.Read.Questions: %s", err)
+			return nil, fmt.Errorf("DNSPacket.Read.Questions: %w", err)
 		}
 		result.Questions = append(result.Questions, question)
 	}
 
-	for i := 0; i < int(result.Header.answerCount); i++ {
-		record, err := readDNSRecord(buffer)
+	for i := 0; i < int(result.Header.AnswerCount); i++ {
+		record, err := ReadDNSRecord(buffer)
 		if err != nil {
-			return nil, fmt.Errorf("DNSPacket.Read.Answers: %s", err)
+			return nil, fmt.Errorf("DNSPacket.Read.Answers: %w", err)
 		}
 		result.Answers = append(result.Answers, record)
 	}
 
-	for i := 0; i < int(result.Header.authoritativeEntries); i++ {
-		record, err := readDNSRecord(buffer)
+	for i := 0; i < int(result.Header.AuthoritativeEntries); i++ {
+		record, err := ReadDNSRecord(buffer)
 		if err != nil {
-			return nil, fmt.Errorf("DNSPacket.Read.Authorities: %s", err)
+			return nil, fmt.Errorf("DNSPacket.Read.Authorities: %w", err)
 		}
 		result.Authorities = append(result.Authorities, record)
 	}
 
-	for i := 0; i < int(result.Header.resourceEntries); i++ {
-		record, err := readDNSRecord(buffer)
+	for i := 0; i < int(result.Header.ResourceEntries); i++ {
+		record, err := ReadDNSRecord(buffer)
 		if err != nil {
-			return nil, fmt.Errorf("DNSPacket.Read.Reources: %s", err)
+			return nil, fmt.Errorf("DNSPacket.Read.Resources: %w", err)
 		}
-		result.Reources = append(result.Reources, record)
+		result.Resources = append(result.Resources, record)
+	}
+
+	// RFC 6891 section 6.1.1: a message carries at most one OPT record.
+	if buffer.strict && countOPT(result.Resources) > 1 {
+		return nil, fmt.Errorf("DNSPacket.Read: %w: more than one OPT record", ErrBadOPT)
+	}
+
+	if buffer.strict && buffer.Pos() != buffer.Len() {
+		return nil, fmt.Errorf("DNSPacket.Read: %w: %d bytes", ErrTrailingBytes, buffer.Len()-buffer.Pos())
 	}
 
 	return result, nil
 }
 
 func (d *DNSPacket) Write(buffer *BytePacketBuffer) error {
-	d.Header.questionCount = uint16(len(d.Questions))
-	d.Header.answerCount = uint16(len(d.Answers))
-	d.Header.authoritativeEntries = uint16(len(d.Authorities))
-	d.Header.resourceEntries = uint16(len(d.Reources))
+	d.Header.QuestionCount = uint16(len(d.Questions))
+	d.Header.AnswerCount = uint16(len(d.Answers))
+	d.Header.AuthoritativeEntries = uint16(len(d.Authorities))
+	d.Header.ResourceEntries = uint16(len(d.Resources))
 
 	if err := d.Header.Write(buffer); err != nil {
-		return fmt.Errorf("DNSPacket.Write.Header: %s", err)
+		return fmt.Errorf("DNSPacket.Write.Header: %w", err)
 	}
 
 	for _, question := range d.Questions {
 		if err := question.Write(buffer); err != nil {
-			return fmt.Errorf("DNSPacket.Write.Questions: %s", err)
+			return fmt.Errorf("DNSPacket.Write.Questions: %w", err)
 		}
 	}
 
 	for _, record := range d.Answers {
 		if _, err := WriteDNSRecord(buffer, record); err != nil {
-			return fmt.Errorf("DNSPacket.Write.Answers: %s", err)
+			return fmt.Errorf("DNSPacket.Write.Answers: %w", err)
 		}
 	}
 
 	for _, record := range d.Authorities {
 		if _, err := WriteDNSRecord(buffer, record); err != nil {
-			return fmt.Errorf("DNSPacket.Write.Authorities: %s", err)
+			return fmt.Errorf("DNSPacket.Write.Authorities: %w", err)
 		}
 
 	}
 
-	for _, record := range d.Reources {
+	for _, record := range d.Resources {
 		if _, err := WriteDNSRecord(buffer, record); err != nil {
-			return fmt.Errorf("DNSPacket.Write.Reources: %s", err)
+			return fmt.Errorf("DNSPacket.Write.Resources: %w", err)
 		}
 	}
 
@@ -111,8 +145,8 @@ func (d *DNSPacket) GetRandomA() (net.IP, error) {
 	var answers []net.IP
 
 	for _, answer := range d.Answers {
-		if record, ok := answer.(*ARecord); ok {
-			answers = append(answers, record.addr)
+		if record, ok := answer.(ARecord); ok {
+			answers = append(answers, record.Addr)
 		}
 	}
 	if len(answers) > 0 {
@@ -123,18 +157,139 @@ func (d *DNSPacket) GetRandomA() (net.IP, error) {
 	return nil, fmt.Errorf("DNSPacket.GetRandomA: No A records found")
 }
 
-func (d *DNSPacket) GetNS(qname string) <-chan struct{ NSDomain, NSHost string } {
-	output := make(chan struct{ NSDomain, NSHost string })
+// GetOrderedSRV returns the SRV answers in the order clients should try
+// them, as described in RFC 2782: ascending priority, and within a
+// priority a weighted random permutation.
+func (d *DNSPacket) GetOrderedSRV() []SRVRecord {
+	byPriority := make(map[uint16][]SRVRecord)
+	var priorities []uint16
+
+	for _, answer := range d.Answers {
+		if record, ok := answer.(SRVRecord); ok {
+			if _, seen := byPriority[record.Priority]; !seen {
+				priorities = append(priorities, record.Priority)
+			}
+			byPriority[record.Priority] = append(byPriority[record.Priority], record)
+		}
+	}
+
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+
+	ordered := make([]SRVRecord, 0)
+	for _, priority := range priorities {
+		ordered = append(ordered, weightedSRVOrder(byPriority[priority])...)
+	}
+
+	return ordered
+}
+
+// srvRandInt63n draws the weighted picks in weightedSRVOrder; tests swap
+// it for a seeded source.
+var srvRandInt63n = rand.Int63n
+
+func weightedSRVOrder(records []SRVRecord) []SRVRecord {
+	// Zero-weight records go first so they only win when the random pick
+	// lands on zero.
+	remaining := make([]SRVRecord, 0, len(records))
+	for _, record := range records {
+		if record.Weight == 0 {
+			remaining = append(remaining, record)
+		}
+	}
+	for _, record := range records {
+		if record.Weight != 0 {
+			remaining = append(remaining, record)
+		}
+	}
+
+	ordered := make([]SRVRecord, 0, len(records))
+	for len(remaining) > 0 {
+		var total uint32
+		for _, record := range remaining {
+			total += uint32(record.Weight)
+		}
+
+		pick := uint32(srvRandInt63n(int64(total) + 1))
+		var running uint32
+		for i, record := range remaining {
+			running += uint32(record.Weight)
+			if running >= pick {
+				ordered = append(ordered, record)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return ordered
+}
+
+// GetOPT returns the EDNS0 OPT record from the additional section, if any.
+func (d *DNSPacket) GetOPT() (OPTRecord, bool) {
+	for _, record := range d.Resources {
+		if record, ok := record.(OPTRecord); ok {
+			return record, true
+		}
+	}
+
+	return OPTRecord{}, false
+}
+
+func countOPT(records []DnsRecord) int {
+	count := 0
+	for _, record := range records {
+		if _, ok := record.(OPTRecord); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// RCode returns the full 12-bit RCODE, combining the header with the upper
+// bits from the OPT record when there is one.
+func (d *DNSPacket) RCode() ResultCode {
+	rcode := d.Header.ResCode & 0x0F
+	if opt, ok := d.GetOPT(); ok {
+		rcode |= ResultCode(opt.ExtendedRCode) << 4
+	}
+	return rcode
+}
+
+// SetRCode splits rcode between the header and the OPT record. Extended
+// values need an OPT record to already be in the additional section.
+func (d *DNSPacket) SetRCode(rcode ResultCode) error {
+	if rcode < 0 || rcode > 0xFFF {
+		return fmt.Errorf("DNSPacket.SetRCode: %s out of range", rcode)
+	}
+
+	for i, record := range d.Resources {
+		if opt, ok := record.(OPTRecord); ok {
+			opt.ExtendedRCode = uint8(rcode >> 4)
+			d.Resources[i] = opt
+			d.Header.ResCode = rcode & 0x0F
+			return nil
+		}
+	}
+
+	if rcode > 0x0F {
+		return fmt.Errorf("DNSPacket.SetRCode: %s needs an OPT record", rcode)
+	}
+	d.Header.ResCode = rcode
+	return nil
+}
+
+func (d *DNSPacket) GetNS(qname DomainName) <-chan struct{ NSDomain, NSHost DomainName } {
+	output := make(chan struct{ NSDomain, NSHost DomainName })
 
 	go func() {
 		defer close(output)
 
 		for _, record := range d.Authorities {
-			if record, ok := record.(*NSRecord); ok {
+			if record, ok := record.(NSRecord); ok {
 
-				if record.domain != "" && record.host != "" {
-					if strings.HasSuffix(qname, record.domain) {
-						output <- struct{ NSDomain, NSHost string }{NSDomain: record.domain, NSHost: record.host}
+				if record.Domain != "" && record.Host != "" {
+					if qname.IsSubdomainOf(record.Domain) {
+						output <- struct{ NSDomain, NSHost DomainName }{NSDomain: record.Domain, NSHost: record.Host}
 					}
 				}
 			}
@@ -144,7 +299,7 @@ func (d *DNSPacket) GetNS(qname string) <-chan struct{ NSDomain, NSHost string }
 	return output
 }
 
-func (d *DNSPacket) GetResolvedNS(qname string) net.IP {
+func (d *DNSPacket) GetResolvedNS(qname DomainName) net.IP {
 	var resolvedIP net.IP
 	nsRecords := d.GetNS(qname)
 
@@ -152,11 +307,11 @@ func (d *DNSPacket) GetResolvedNS(qname string) net.IP {
 		host := nsRecord.NSHost
 
 		// Look for a matching A record in the additional section
-		for _, record := range d.Reources {
-			if record, ok := record.(*ARecord); ok {
+		for _, record := range d.Resources {
+			if record, ok := record.(ARecord); ok {
 
-				if record.domain == host && record.addr != nil {
-					resolvedIP = record.addr
+				if record.Domain.Equal(host) && record.Addr != nil {
+					resolvedIP = record.Addr
 					break
 				}
 			}
@@ -0,0 +1,119 @@
+package dns
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestReadRejectsBadOPT(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []DnsRecord
+	}{
+		{
+			name:      "two OPT records",
+			resources: []DnsRecord{NewOPTRecord(1232, false), NewOPTRecord(4096, true)},
+		},
+		{
+			name:      "OPT owner is not the root",
+			resources: []DnsRecord{UnknownRecord{Domain: "example.com", Class: 1232, Type: uint16(OPT), Data: []byte{}}},
+		},
+	}
+
+	for _, tt := range tests {
+		packet := NewDNSPacket()
+		packet.Questions = append(packet.Questions, NewDNSQuestion("example.com", A))
+		packet.Resources = tt.resources
+
+		if _, err := roundTrip(t, packet, true); !errors.Is(err, ErrBadOPT) {
+			t.Errorf("%s: strict read got %v, want %v", tt.name, err, ErrBadOPT)
+		}
+		var formatErr *FormatError
+		if _, err := roundTrip(t, packet, true); !errors.As(err, &formatErr) {
+			t.Errorf("%s: strict read got %v, want a *FormatError", tt.name, err)
+		}
+		if _, err := roundTrip(t, packet, false); err != nil {
+			t.Errorf("%s: lenient read: %v", tt.name, err)
+		}
+	}
+}
+
+func TestGetRandomA(t *testing.T) {
+	packet := NewDNSPacket()
+	if _, err := packet.GetRandomA(); err == nil {
+		t.Errorf("GetRandomA on an empty answer: got no error")
+	}
+
+	addr := net.IP{192, 0, 2, 1}
+	packet.Answers = append(packet.Answers,
+		CNameRecord{Domain: "www.example.com", Class: ClassIN, Host: "example.com", TTL: 300},
+		ARecord{Domain: "example.com", Class: ClassIN, Addr: addr, TTL: 300},
+	)
+	got, err := packet.GetRandomA()
+	if err != nil {
+		t.Fatalf("GetRandomA: %v", err)
+	}
+	if !got.Equal(addr) {
+		t.Errorf("GetRandomA = %v, want %v", got, addr)
+	}
+}
+
+func TestGetOrderedSRV(t *testing.T) {
+	srv := func(priority, weight uint16, target DomainName) SRVRecord {
+		return SRVRecord{Domain: "_sip._tcp.example.com", Class: ClassIN, Priority: priority, Weight: weight, Port: 5060, Target: target, TTL: 300}
+	}
+	setRand := func(int63n func(int64) int64) {
+		saved := srvRandInt63n
+		srvRandInt63n = int63n
+		t.Cleanup(func() { srvRandInt63n = saved })
+	}
+	targets := func(records []SRVRecord) []DomainName {
+		names := make([]DomainName, 0, len(records))
+		for _, record := range records {
+			names = append(names, record.Target)
+		}
+		return names
+	}
+
+	packet := NewDNSPacket()
+	packet.Answers = []DnsRecord{
+		srv(20, 0, "c"),
+		srv(10, 30, "a"),
+		ARecord{Domain: "a.example.com", Class: ClassIN, Addr: net.IP{192, 0, 2, 1}, TTL: 300},
+		srv(30, 5, "d"),
+		srv(10, 0, "b"),
+	}
+
+	tests := []struct {
+		name  string
+		pick  func(n int64) int64
+		order []DomainName
+	}{
+		// A pick of zero lands on the zero-weight record b first.
+		{"lowest pick", func(n int64) int64 { return 0 }, []DomainName{"b", "a", "c", "d"}},
+		// The highest pick passes over b, so it only comes last.
+		{"highest pick", func(n int64) int64 { return n - 1 }, []DomainName{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		setRand(tt.pick)
+		if got := targets(packet.GetOrderedSRV()); !reflect.DeepEqual(got, tt.order) {
+			t.Errorf("%s: order = %v, want %v", tt.name, got, tt.order)
+		}
+	}
+
+	// With a seeded source the weights decide how often each record of a
+	// priority goes first.
+	setRand(rand.New(rand.NewSource(1)).Int63n)
+	packet.Answers = []DnsRecord{srv(10, 90, "heavy"), srv(10, 10, "light"), srv(10, 0, "zero")}
+	first := make(map[DomainName]int)
+	const runs = 10000
+	for i := 0; i < runs; i++ {
+		first[packet.GetOrderedSRV()[0].Target]++
+	}
+	if first["heavy"] < runs*85/100 || first["light"] < runs*5/100 || first["zero"] > runs*2/100 {
+		t.Errorf("first picks over %d runs = %v, want about 90%% heavy, 10%% light, ~0 zero", runs, first)
+	}
+}
@@ -1,16 +1,18 @@
-package main
+package dns
 
 import "fmt"
 
 type DNSQuestion struct {
-	Name string
-	Type QueryType
+	Name  DomainName
+	Type  QueryType
+	Class Class
 }
 
-func NewDNSQuestion(name string, qt QueryType) *DNSQuestion {
+func NewDNSQuestion(name DomainName, qt QueryType) *DNSQuestion {
 	return &DNSQuestion{
-		Name: name,
-		Type: qt,
+		Name:  name,
+		Type:  qt,
+		Class: ClassIN,
 	}
 }
 
@@ -26,10 +28,11 @@ func (q *DNSQuestion) Read(b *BytePacketBuffer) error {
 	}
 
 	q.Type = QueryType(by)
-	_, err = b.ReadU16()
+	class, err := b.ReadU16()
 	if err != nil {
 		return err
 	}
+	q.Class = Class(class)
 
 	return nil
 }
@@ -43,7 +46,7 @@ func (q *DNSQuestion) Write(b *BytePacketBuffer) error {
 		return err
 	}
 
-	if err := b.WriteU16(1); err != nil {
+	if err := b.WriteU16(uint16(q.Class)); err != nil {
 		return err
 	}
 
@@ -51,5 +54,5 @@ func (q *DNSQuestion) Write(b *BytePacketBuffer) error {
 }
 
 func (q *DNSQuestion) String() string {
-	return fmt.Sprintf("%s %s", q.Name, q.Type)
+	return fmt.Sprintf("%s %s %s", fqdn(q.Name), q.Class, q.Type)
 }
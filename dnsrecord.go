@@ -1,15 +1,19 @@
-package main
+package dns
 
 import (
 	"fmt"
 	"net"
+	"strings"
 )
 
+// UnknownRecord carries a record of a type we have no codec for. The RDATA
+// is kept verbatim so it can be forwarded unchanged (RFC 3597).
 type UnknownRecord struct {
-	domain     string
-	qtype      uint16
-	dataLength uint16
-	ttl        uint32
+	Domain DomainName
+	Class  Class
+	Type   uint16
+	Data   []byte
+	TTL    uint32
 }
 
 func (UnknownRecord) isDnsRecord() {}
@@ -19,13 +23,22 @@ func (UnknownRecord) Name() string {
 }
 
 func (record UnknownRecord) String() string {
-	return fmt.Sprintf("%s %d %d %d", record.domain, record.ttl, record.qtype, record.dataLength)
+	return formatRecord(record.Domain, record.TTL, record.Class, QueryType(record.Type), genericRData(record.Data))
+}
+
+// genericRData renders RDATA in the RFC 3597 generic form "\# len hex".
+func genericRData(data []byte) string {
+	if len(data) == 0 {
+		return "\\# 0"
+	}
+	return fmt.Sprintf("\\# %d %X", len(data), data)
 }
 
 type ARecord struct {
-	domain string
-	addr   net.IP
-	ttl    uint32
+	Domain DomainName
+	Class  Class
+	Addr   net.IP
+	TTL    uint32
 }
 
 func (ARecord) isDnsRecord() {}
@@ -35,13 +48,14 @@ func (record ARecord) Name() string {
 }
 
 func (record ARecord) String() string {
-	return fmt.Sprintf("%s %d %s", record.domain, record.ttl, record.addr)
+	return formatRecord(record.Domain, record.TTL, record.Class, A, record.Addr.String())
 }
 
 type NSRecord struct {
-	domain string
-	host   string
-	ttl    uint32
+	Domain DomainName
+	Class  Class
+	Host   DomainName
+	TTL    uint32
 }
 
 func (NSRecord) isDnsRecord() {}
@@ -51,13 +65,14 @@ func (record NSRecord) Name() string {
 }
 
 func (record NSRecord) String() string {
-	return fmt.Sprintf("%s %d %s", record.domain, record.ttl, record.host)
+	return formatRecord(record.Domain, record.TTL, record.Class, NS, fqdn(record.Host))
 }
 
 type CNameRecord struct {
-	domain string
-	host   string
-	ttl    uint32
+	Domain DomainName
+	Class  Class
+	Host   DomainName
+	TTL    uint32
 }
 
 func (CNameRecord) isDnsRecord() {}
@@ -67,14 +82,72 @@ func (record CNameRecord) Name() string {
 }
 
 func (record CNameRecord) String() string {
-	return fmt.Sprintf("%s %d %s", record.domain, record.ttl, record.host)
+	return formatRecord(record.Domain, record.TTL, record.Class, CNAME, fqdn(record.Host))
+}
+
+type SOARecord struct {
+	Domain  DomainName
+	Class   Class
+	MName   DomainName
+	RName   DomainName
+	Serial  uint32
+	Refresh uint32
+	Retry   uint32
+	Expire  uint32
+	Minimum uint32
+	TTL     uint32
+}
+
+func (SOARecord) isDnsRecord() {}
+
+func (record SOARecord) Name() string {
+	return "SOA"
+}
+
+func (record SOARecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, SOA, fmt.Sprintf("%s %s %d %d %d %d %d", fqdn(record.MName), fqdn(record.RName), record.Serial, record.Refresh, record.Retry, record.Expire, record.Minimum))
+}
+
+type PTRRecord struct {
+	Domain DomainName
+	Class  Class
+	Host   DomainName
+	TTL    uint32
+}
+
+func (PTRRecord) isDnsRecord() {}
+
+func (record PTRRecord) Name() string {
+	return "PTR"
+}
+
+func (record PTRRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, PTR, fqdn(record.Host))
+}
+
+type DNAMERecord struct {
+	Domain DomainName
+	Class  Class
+	Target DomainName
+	TTL    uint32
+}
+
+func (DNAMERecord) isDnsRecord() {}
+
+func (record DNAMERecord) Name() string {
+	return "DNAME"
+}
+
+func (record DNAMERecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, DNAME, fqdn(record.Target))
 }
 
 type MXRecord struct {
-	domain string
-	prio   uint16
-	host   string
-	ttl    uint32
+	Domain   DomainName
+	Class    Class
+	Priority uint16
+	Host     DomainName
+	TTL      uint32
 }
 
 func (MXRecord) isDnsRecord() {}
@@ -84,13 +157,14 @@ func (record MXRecord) Name() string {
 }
 
 func (record MXRecord) String() string {
-	return fmt.Sprintf("%s %d %s", record.domain, record.ttl, record.host)
+	return formatRecord(record.Domain, record.TTL, record.Class, MX, fmt.Sprintf("%d %s", record.Priority, fqdn(record.Host)))
 }
 
 type AAAARecord struct {
-	domain string
-	addr   net.IP
-	ttl    uint32
+	Domain DomainName
+	Class  Class
+	Addr   net.IP
+	TTL    uint32
 }
 
 func (AAAARecord) isDnsRecord() {}
@@ -100,7 +174,149 @@ func (record AAAARecord) Name() string {
 }
 
 func (record AAAARecord) String() string {
-	return fmt.Sprintf("%s %d %s", record.domain, record.ttl, record.addr)
+	return formatRecord(record.Domain, record.TTL, record.Class, AAAA, formatIPv6(record.Addr))
+}
+
+type TXTRecord struct {
+	Domain DomainName
+	Class  Class
+	Text   []string
+	TTL    uint32
+}
+
+func (TXTRecord) isDnsRecord() {}
+
+func (record TXTRecord) Name() string {
+	return "TXT"
+}
+
+func (record TXTRecord) String() string {
+	quoted := make([]string, 0, len(record.Text))
+	for _, txt := range record.Text {
+		quoted = append(quoted, quoteCharacterString(txt))
+	}
+	return formatRecord(record.Domain, record.TTL, record.Class, TXT, strings.Join(quoted, " "))
+}
+
+// quoteCharacterString renders s the way zone files do: wrapped in double
+// quotes, with '"' and '\' backslash-escaped and non-printable bytes
+// written as \DDD.
+func quoteCharacterString(s string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, c := range []byte(s) {
+		switch {
+		case c == '"' || c == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case c < 0x20 || c > 0x7E:
+			fmt.Fprintf(&builder, "\\%03d", c)
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+type SRVRecord struct {
+	Domain   DomainName
+	Class    Class
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	Target   DomainName
+	TTL      uint32
+}
+
+func (SRVRecord) isDnsRecord() {}
+
+func (record SRVRecord) Name() string {
+	return "SRV"
+}
+
+func (record SRVRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, SRV, fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, fqdn(record.Target)))
+}
+
+type NAPTRRecord struct {
+	Domain      DomainName
+	Class       Class
+	Order       uint16
+	Preference  uint16
+	Flags       string
+	Services    string
+	Regexp      string
+	Replacement DomainName
+	TTL         uint32
+}
+
+func (NAPTRRecord) isDnsRecord() {}
+
+func (record NAPTRRecord) Name() string {
+	return "NAPTR"
+}
+
+func (record NAPTRRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, NAPTR, fmt.Sprintf("%d %d %s %s %s %s", record.Order, record.Preference, quoteCharacterString(record.Flags), quoteCharacterString(record.Services), quoteCharacterString(record.Regexp), fqdn(record.Replacement)))
+}
+
+type SSHFPRecord struct {
+	Domain      DomainName
+	Class       Class
+	Algorithm   uint8
+	FPType      uint8
+	Fingerprint []byte
+	TTL         uint32
+}
+
+func (SSHFPRecord) isDnsRecord() {}
+
+func (record SSHFPRecord) Name() string {
+	return "SSHFP"
+}
+
+func (record SSHFPRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, SSHFP, fmt.Sprintf("%d %d %X", record.Algorithm, record.FPType, record.Fingerprint))
+}
+
+type TLSARecord struct {
+	Domain       DomainName
+	Class        Class
+	Usage        uint8
+	Selector     uint8
+	MatchingType uint8
+	Data         []byte
+	TTL          uint32
+}
+
+func (TLSARecord) isDnsRecord() {}
+
+func (record TLSARecord) Name() string {
+	return "TLSA"
+}
+
+func (record TLSARecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, TLSA, fmt.Sprintf("%d %d %d %X", record.Usage, record.Selector, record.MatchingType, record.Data))
+}
+
+type CAARecord struct {
+	Domain DomainName
+	Class  Class
+	Flags  uint8
+	Tag    string
+	Value  string
+	TTL    uint32
+}
+
+func (CAARecord) isDnsRecord() {}
+
+func (record CAARecord) Name() string {
+	return "CAA"
+}
+
+func (record CAARecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, CAA, fmt.Sprintf("%d %s %s", record.Flags, record.Tag, quoteCharacterString(record.Value)))
 }
 
 type DnsRecord interface {
@@ -109,57 +325,94 @@ type DnsRecord interface {
 	String() string
 }
 
-func readDNSRecord(buffer *BytePacketBuffer) (DnsRecord, error) {
-	var domain string
+func ReadDNSRecord(buffer *BytePacketBuffer) (DnsRecord, error) {
+	var domain DomainName
 	err := buffer.ReadQName(&domain)
 	if err != nil {
-		return nil, fmt.Errorf("readDNSRecord.ReadQName: %s", err)
+		return nil, fmt.Errorf("ReadDNSRecord.ReadQName: %w", err)
 	}
 	qtypeNum, err := buffer.ReadU16()
 	if err != nil {
-		return nil, fmt.Errorf("readDNSRecord.ReadU16.qtypeNum: %s", err)
+		return nil, fmt.Errorf("ReadDNSRecord.ReadU16.qtypeNum: %w", err)
 	}
 	qtype := QueryType(qtypeNum)
-	_, err = buffer.ReadU16()
+	class, err := buffer.ReadU16()
 	if err != nil {
-		return nil, fmt.Errorf("readDNSRecord.ReadU16.noname: %s", err)
+		return nil, fmt.Errorf("ReadDNSRecord.ReadU16.class: %w", err)
 	}
 	ttl, err := buffer.ReadU32()
 	if err != nil {
-		return nil, fmt.Errorf("readDNSRecord.ReadU32.ttl: %s", err)
+		return nil, fmt.Errorf("ReadDNSRecord.ReadU32.ttl: %w", err)
 	}
 	dataLength, err := buffer.ReadU16()
 	if err != nil {
-		return nil, fmt.Errorf("readDNSRecord.ReadU16.dataLength: %s", err)
+		return nil, fmt.Errorf("ReadDNSRecord.ReadU16.dataLength: %w", err)
 	}
+	start := buffer.Pos()
+	if start+uint(dataLength) > buffer.Len() {
+		return nil, fmt.Errorf("ReadDNSRecord: %w: RDLENGTH %d past end of message", ErrEndOfBuffer, dataLength)
+	}
+
+	record, err := readRData(buffer, domain, qtype, class, ttl, dataLength)
+	if err != nil {
+		return nil, err
+	}
+
+	if consumed := buffer.Pos() - start; consumed != uint(dataLength) {
+		if buffer.strict {
+			return nil, fmt.Errorf("ReadDNSRecord: %w: %s read %d bytes of %d", ErrRDataLength, qtype, consumed, dataLength)
+		}
+		if consumed < uint(dataLength) {
+			// Trailing bytes in the RDATA are skipped.
+			buffer.Seek(start + uint(dataLength))
+			return record, nil
+		}
+
+		// The decoded fields ran into the next record, so they cannot be
+		// trusted; keep the RDATA as opaque bytes instead.
+		buffer.Seek(start)
+		data, err := buffer.ReadBytes(uint(dataLength))
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadBytes.data: %w", err)
+		}
+		return UnknownRecord{domain, Class(class), uint16(qtype), data, ttl}, nil
+	}
+
+	return record, nil
+}
+
+// readRData decodes the RDATA of a record whose header ReadDNSRecord has
+// already consumed.
+func readRData(buffer *BytePacketBuffer, domain DomainName, qtype QueryType, class uint16, ttl uint32, dataLength uint16) (DnsRecord, error) {
+	start := buffer.Pos()
 	switch qtype {
 	case A:
 		rawAddr, err := buffer.ReadU32()
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadU32.rawAddr: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU32.rawAddr: %w", err)
 		}
 		addr := net.IPv4(
 			uint8(rawAddr>>24&0xFF),
 			uint8(rawAddr>>16&0xFF),
 			uint8(rawAddr>>8&0xFF),
 			uint8(rawAddr&0xFF))
-		return ARecord{domain, addr, ttl}, nil
+		return ARecord{domain, Class(class), addr, ttl}, nil
 	case AAAA:
 		rawAddr1, err := buffer.ReadU32()
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadU16.rawAddr: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.rawAddr: %w", err)
 		}
 		rawAddr2, err := buffer.ReadU32()
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadU16.rawAddr: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.rawAddr: %w", err)
 		}
 		rawAddr3, err := buffer.ReadU32()
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadU16.rawAddr: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.rawAddr: %w", err)
 		}
 		rawAddr4, err := buffer.ReadU32()
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadU16.rawAddr: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.rawAddr: %w", err)
 		}
 
 		addr := net.IP{
@@ -168,194 +421,712 @@ func readDNSRecord(buffer *BytePacketBuffer) (DnsRecord, error) {
 			byte((rawAddr3 >> 24) & 0xFF), byte((rawAddr3 >> 16) & 0xFF), byte((rawAddr3 >> 8) & 0xFF), byte(rawAddr3 & 0xFF),
 			byte((rawAddr4 >> 24) & 0xFF), byte((rawAddr4 >> 16) & 0xFF), byte((rawAddr4 >> 8) & 0xFF), byte(rawAddr4 & 0xFF),
 		}
-		return AAAARecord{domain, addr, ttl}, nil
+		return AAAARecord{domain, Class(class), addr, ttl}, nil
 
 	case NS:
-		ns := ""
+		var ns DomainName
 		err := buffer.ReadQName(&ns)
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadQName.ns: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.ns: %w", err)
 		}
 
-		return NSRecord{domain, ns, ttl}, nil
+		return NSRecord{domain, Class(class), ns, ttl}, nil
 
 	case CNAME:
-		cname := ""
+		var cname DomainName
 		err := buffer.ReadQName(&cname)
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadQName.cname: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.cname: %w", err)
 		}
 
-		return CNameRecord{domain, cname, ttl}, nil
+		return CNameRecord{domain, Class(class), cname, ttl}, nil
+	case SOA:
+		var mname DomainName
+		if err := buffer.ReadQName(&mname); err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.mname: %w", err)
+		}
+
+		var rname DomainName
+		if err := buffer.ReadQName(&rname); err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.rname: %w", err)
+		}
+
+		serial, err := buffer.ReadU32()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU32.serial: %w", err)
+		}
+		refresh, err := buffer.ReadU32()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU32.refresh: %w", err)
+		}
+		retry, err := buffer.ReadU32()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU32.retry: %w", err)
+		}
+		expire, err := buffer.ReadU32()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU32.expire: %w", err)
+		}
+		minimum, err := buffer.ReadU32()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU32.minimum: %w", err)
+		}
+
+		return SOARecord{domain, Class(class), mname, rname, serial, refresh, retry, expire, minimum, ttl}, nil
+
+	case PTR:
+		var ptr DomainName
+		err := buffer.ReadQName(&ptr)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.ptr: %w", err)
+		}
+
+		return PTRRecord{domain, Class(class), ptr, ttl}, nil
+
+	case DNAME:
+		var target DomainName
+		if err := buffer.ReadQName(&target); err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.target: %w", err)
+		}
+
+		return DNAMERecord{domain, Class(class), target, ttl}, nil
+
 	case MX:
 		prio, err := buffer.ReadU16()
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadU16.prio: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.prio: %w", err)
 		}
 
-		mx := ""
+		var mx DomainName
 		err = buffer.ReadQName(&mx)
 		if err != nil {
-			return nil, fmt.Errorf("readDNSRecord.ReadQName.mx: %s", err)
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.mx: %w", err)
+		}
+
+		return MXRecord{domain, Class(class), prio, mx, ttl}, nil
+
+	case TXT:
+		txt := make([]string, 0)
+		end := buffer.Pos() + uint(dataLength)
+		for buffer.Pos() < end {
+			s, err := buffer.ReadCharacterString()
+			if err != nil {
+				return nil, fmt.Errorf("ReadDNSRecord.ReadCharacterString.txt: %w", err)
+			}
+			txt = append(txt, s)
+		}
+
+		return TXTRecord{domain, Class(class), txt, ttl}, nil
+
+	case SRV:
+		priority, err := buffer.ReadU16()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.priority: %w", err)
+		}
+		weight, err := buffer.ReadU16()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.weight: %w", err)
+		}
+		port, err := buffer.ReadU16()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.port: %w", err)
+		}
+
+		var target DomainName
+		if err := buffer.ReadQName(&target); err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.target: %w", err)
+		}
+
+		return SRVRecord{domain, Class(class), priority, weight, port, target, ttl}, nil
+
+	case NAPTR:
+		order, err := buffer.ReadU16()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.order: %w", err)
+		}
+		preference, err := buffer.ReadU16()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadU16.preference: %w", err)
+		}
+		flags, err := buffer.ReadCharacterString()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadCharacterString.flags: %w", err)
+		}
+		services, err := buffer.ReadCharacterString()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadCharacterString.services: %w", err)
+		}
+		regexp, err := buffer.ReadCharacterString()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadCharacterString.regexp: %w", err)
+		}
+
+		var replacement DomainName
+		if err := buffer.ReadQName(&replacement); err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadQName.replacement: %w", err)
+		}
+
+		return NAPTRRecord{domain, Class(class), order, preference, flags, services, regexp, replacement, ttl}, nil
+
+	case SSHFP:
+		algorithm, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.Read.algorithm: %w", err)
+		}
+		fpType, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.Read.fpType: %w", err)
+		}
+
+		left, err := remaining(buffer, start, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.SSHFP: %w", err)
+		}
+		fingerprint, err := buffer.ReadBytes(left)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadBytes.fingerprint: %w", err)
+		}
+
+		return SSHFPRecord{domain, Class(class), algorithm, fpType, fingerprint, ttl}, nil
+
+	case TLSA:
+		usage, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.Read.usage: %w", err)
+		}
+		selector, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.Read.selector: %w", err)
+		}
+		matchingType, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.Read.matchingType: %w", err)
+		}
+
+		left, err := remaining(buffer, start, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.TLSA: %w", err)
+		}
+		data, err := buffer.ReadBytes(left)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadBytes.data: %w", err)
+		}
+
+		return TLSARecord{domain, Class(class), usage, selector, matchingType, data, ttl}, nil
+
+	case CAA:
+		flags, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.Read.flags: %w", err)
+		}
+		tag, err := buffer.ReadCharacterString()
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadCharacterString.tag: %w", err)
+		}
+
+		left, err := remaining(buffer, start, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.CAA: %w", err)
+		}
+		value, err := buffer.ReadBytes(left)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadBytes.value: %w", err)
 		}
 
-		return MXRecord{domain, prio, mx, ttl}, nil
+		return CAARecord{domain, Class(class), flags, tag, string(value), ttl}, nil
+
+	case DS:
+		ds, err := readDSRecord(buffer, domain, Class(class), ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readDSRecord: %w", err)
+		}
+
+		return ds, nil
+
+	case DNSKEY:
+		dnskey, err := readDNSKEYRecord(buffer, domain, Class(class), ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readDNSKEYRecord: %w", err)
+		}
+
+		return dnskey, nil
+
+	case RRSIG:
+		rrsig, err := readRRSIGRecord(buffer, domain, Class(class), ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readRRSIGRecord: %w", err)
+		}
+
+		return rrsig, nil
+
+	case NSEC:
+		nsec, err := readNSECRecord(buffer, domain, Class(class), ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readNSECRecord: %w", err)
+		}
+
+		return nsec, nil
+
+	case NSEC3:
+		nsec3, err := readNSEC3Record(buffer, domain, Class(class), ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readNSEC3Record: %w", err)
+		}
+
+		return nsec3, nil
+
+	case NSEC3PARAM:
+		nsec3param, err := readNSEC3PARAMRecord(buffer, domain, Class(class), ttl)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readNSEC3PARAMRecord: %w", err)
+		}
+
+		return nsec3param, nil
+
+	case SVCB:
+		svcb, err := readSVCBRecord(buffer, domain, Class(class), ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readSVCBRecord: %w", err)
+		}
+
+		return svcb, nil
+
+	case HTTPS:
+		https, err := readSVCBRecord(buffer, domain, Class(class), ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readSVCBRecord: %w", err)
+		}
+
+		return HTTPSRecord(https), nil
+
+	case OPT:
+		// RFC 6891 section 6.1.2: the owner of an OPT record is the root.
+		if buffer.strict && domain != "" {
+			return nil, fmt.Errorf("ReadDNSRecord: %w: owner %q", ErrBadOPT, domain)
+		}
+		opt, err := readOPTRecord(buffer, class, ttl, dataLength)
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.readOPTRecord: %w", err)
+		}
+
+		return opt, nil
 
 	default:
-		buffer.Step(uint(dataLength))
-		return UnknownRecord{domain, qtypeNum, dataLength, ttl}, nil
+		data, err := buffer.ReadBytes(uint(dataLength))
+		if err != nil {
+			return nil, fmt.Errorf("ReadDNSRecord.ReadBytes.data: %w", err)
+		}
+
+		return UnknownRecord{domain, Class(class), uint16(qtype), data, ttl}, nil
+
+	}
+
+}
+
+// writeRecordHeader writes the owner, type, class and TTL of a record
+// followed by a placeholder RDLENGTH. It returns the position of that
+// placeholder for closeRecord.
+func writeRecordHeader(buffer *BytePacketBuffer, domain DomainName, qtype QueryType, class Class, ttl uint32) (uint, error) {
+	if err := buffer.WriteQName(&domain); err != nil {
+		return 0, fmt.Errorf("WriteQName: %w", err)
+	}
+
+	if err := buffer.WriteU16(uint16(qtype)); err != nil {
+		return 0, fmt.Errorf("WriteU16.qtype: %w", err)
+	}
+
+	if err := buffer.WriteU16(uint16(class)); err != nil {
+		return 0, fmt.Errorf("WriteU16.class: %w", err)
+	}
+
+	if err := buffer.WriteU32(ttl); err != nil {
+		return 0, fmt.Errorf("WriteU32.ttl: %w", err)
+	}
+
+	pos := buffer.Pos()
+	if err := buffer.WriteU16(0); err != nil {
+		return 0, fmt.Errorf("WriteU16.dataLength: %w", err)
+	}
 
+	return pos, nil
+}
+
+// remaining returns how many RDATA bytes are left after start, or an error
+// if the fixed fields already ran past dataLength.
+func remaining(buffer *BytePacketBuffer, start uint, dataLength uint16) (uint, error) {
+	consumed := buffer.Pos() - start
+	if consumed > uint(dataLength) {
+		return 0, fmt.Errorf("RDATA overrun by %d bytes", consumed-uint(dataLength))
 	}
+	return uint(dataLength) - consumed, nil
+}
 
+// closeRecord back-fills the RDLENGTH reserved by writeRecordHeader.
+func closeRecord(buffer *BytePacketBuffer, pos uint) error {
+	size := buffer.Pos() - (pos + 2)
+	return buffer.SetU16(pos, uint16(size))
 }
 
 func WriteDNSRecord(buffer *BytePacketBuffer, record DnsRecord) (uint, error) {
 	startPos := buffer.Pos()
 	switch record := record.(type) {
 	case ARecord:
-		if err := buffer.WriteQName(&record.domain); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		octets := record.Addr.To4()
+		if octets == nil {
+			return 0, fmt.Errorf("WriteDNSRecord: A address %v is not IPv4", record.Addr)
 		}
 
-		if err := buffer.WriteU16(uint16(A)); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.qtype: %s", err)
-		}
-		if err := buffer.WriteU16(1); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.class: %s", err)
-		}
-		if err := buffer.WriteU32(record.ttl); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.ttl: %s", err)
-		}
-		if err := buffer.WriteU16(4); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.dataLength: %s", err)
+		pos, err := writeRecordHeader(buffer, record.Domain, A, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
 		}
-		octets := record.addr.To4()
+
 		if err := buffer.WriteU8(octets[0]); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[0]: %s", err)
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[0]: %w", err)
 		}
 		if err := buffer.WriteU8(octets[1]); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[1]: %s", err)
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[1]: %w", err)
 		}
 		if err := buffer.WriteU8(octets[2]); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[2]: %s", err)
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[2]: %w", err)
 		}
 
 		if err := buffer.WriteU8(octets[3]); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[3]: %s", err)
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octets[3]: %w", err)
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
 		}
 	case NSRecord:
-		if err := buffer.WriteQName(&record.domain); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		pos, err := writeRecordHeader(buffer, record.Domain, NS, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
 		}
 
-		if err := buffer.WriteU16(uint16(NS)); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.qtype: %s", err)
+		if err := buffer.WriteQName(&record.Host); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %w", err)
 		}
 
-		if err := buffer.WriteU16(1); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.class: %s", err)
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+	case CNameRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, CNAME, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
 		}
 
-		if err := buffer.WriteU32(record.ttl); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.ttl: %s", err)
+		if err := buffer.WriteQName(&record.Host); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %w", err)
 		}
 
-		pos := buffer.Pos()
-		buffer.WriteU16(0)
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+	case SOARecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, SOA, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
 
-		if err := buffer.WriteQName(&record.host); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		if err := buffer.WriteQName(&record.MName); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQName.mname: %w", err)
 		}
 
-		size := buffer.Pos() - (pos + 2)
-		buffer.SetU16(pos, uint16(size))
-	case CNameRecord:
-		if err := buffer.WriteQName(&record.domain); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		if err := buffer.WriteQName(&record.RName); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQName.rname: %w", err)
 		}
 
-		if err := buffer.WriteU16(uint16(CNAME)); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.qtype: %s", err)
+		if err := buffer.WriteU32(record.Serial); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.serial: %w", err)
 		}
 
-		if err := buffer.WriteU16(1); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.class: %s", err)
+		if err := buffer.WriteU32(record.Refresh); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.refresh: %w", err)
 		}
 
-		if err := buffer.WriteU32(record.ttl); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.ttl: %s", err)
+		if err := buffer.WriteU32(record.Retry); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.retry: %w", err)
 		}
 
-		pos := buffer.Pos()
-		buffer.WriteU16(0)
+		if err := buffer.WriteU32(record.Expire); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.expire: %w", err)
+		}
 
-		if err := buffer.WriteQName(&record.host); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		if err := buffer.WriteU32(record.Minimum); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.minimum: %w", err)
 		}
 
-		size := buffer.Pos() - (pos + 2)
-		buffer.SetU16(pos, uint16(size))
-	case MXRecord:
-		if err := buffer.WriteQName(&record.domain); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
 		}
 
-		if err := buffer.WriteU16(uint16(MX)); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.qtype: %s", err)
+	case PTRRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, PTR, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
 		}
 
-		if err := buffer.WriteU16(1); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.class: %s", err)
+		if err := buffer.WriteQName(&record.Host); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %w", err)
 		}
 
-		if err := buffer.WriteU32(record.ttl); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.ttl: %s", err)
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
 		}
 
-		pos := buffer.Pos()
-		buffer.WriteU16(0)
+	case DNAMERecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, DNAME, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
 
-		if err := buffer.WriteU16(record.prio); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.prio: %s", err)
+		// RFC 6672 section 2.5: the target must not be compressed.
+		if err := buffer.WriteQNameUncompressed(&record.Target); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQNameUncompressed.target: %w", err)
 		}
 
-		if err := buffer.WriteQName(&record.host); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
 		}
 
-		size := buffer.Pos() - (pos + 2)
-		buffer.SetU16(pos, uint16(size))
+	case MXRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, MX, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
 
-	case AAAARecord:
-		if err := buffer.WriteQName(&record.domain); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %s", err)
+		if err := buffer.WriteU16(record.Priority); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.prio: %w", err)
 		}
 
-		if err := buffer.WriteU16(uint16(AAAA)); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.qtype: %s", err)
+		if err := buffer.WriteQName(&record.Host); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQName: %w", err)
 		}
 
-		if err := buffer.WriteU16(1); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.class: %s", err)
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
 		}
 
-		if err := buffer.WriteU32(record.ttl); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU32.ttl: %s", err)
+	case AAAARecord:
+		octets := record.Addr.To16()
+		if octets == nil || len(record.Addr) == net.IPv4len {
+			return 0, fmt.Errorf("WriteDNSRecord: AAAA address %v is not IPv6", record.Addr)
 		}
 
-		if err := buffer.WriteU16(16); err != nil {
-			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.dataLength: %s", err)
+		pos, err := writeRecordHeader(buffer, record.Domain, AAAA, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
 		}
 
-		octets := record.addr.To16()
 		for _, octet := range octets {
 			if err := buffer.WriteU8(octet); err != nil {
-				return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octet: %s", err)
+				return 0, fmt.Errorf("WriteDNSRecord.WriteU8.octet: %w", err)
 			}
 		}
 
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
+	case TXTRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, TXT, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
+
+		for _, txt := range record.Text {
+			if err := buffer.WriteCharacterString(txt); err != nil {
+				return 0, fmt.Errorf("WriteDNSRecord.WriteCharacterString.txt: %w", err)
+			}
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
+	case SRVRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, SRV, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
+
+		if err := buffer.WriteU16(record.Priority); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.priority: %w", err)
+		}
+
+		if err := buffer.WriteU16(record.Weight); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.weight: %w", err)
+		}
+
+		if err := buffer.WriteU16(record.Port); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.port: %w", err)
+		}
+
+		// RFC 2782: the target must not be compressed.
+		if err := buffer.WriteQNameUncompressed(&record.Target); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQNameUncompressed.target: %w", err)
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
+	case NAPTRRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, NAPTR, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
+
+		if err := buffer.WriteU16(record.Order); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.order: %w", err)
+		}
+		if err := buffer.WriteU16(record.Preference); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU16.preference: %w", err)
+		}
+		if err := buffer.WriteCharacterString(record.Flags); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteCharacterString.flags: %w", err)
+		}
+		if err := buffer.WriteCharacterString(record.Services); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteCharacterString.services: %w", err)
+		}
+		if err := buffer.WriteCharacterString(record.Regexp); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteCharacterString.regexp: %w", err)
+		}
+		// RFC 3403 section 4.1: the replacement must not be compressed.
+		if err := buffer.WriteQNameUncompressed(&record.Replacement); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteQNameUncompressed.replacement: %w", err)
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
+	case SSHFPRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, SSHFP, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
+
+		if err := buffer.WriteU8(record.Algorithm); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.algorithm: %w", err)
+		}
+		if err := buffer.WriteU8(record.FPType); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.fpType: %w", err)
+		}
+		if err := buffer.WriteBytes(record.Fingerprint); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteBytes.fingerprint: %w", err)
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
+	case TLSARecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, TLSA, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
+
+		if err := buffer.WriteU8(record.Usage); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.usage: %w", err)
+		}
+		if err := buffer.WriteU8(record.Selector); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.selector: %w", err)
+		}
+		if err := buffer.WriteU8(record.MatchingType); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.matchingType: %w", err)
+		}
+		if err := buffer.WriteBytes(record.Data); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteBytes.data: %w", err)
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
+	case CAARecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, CAA, record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
+
+		if err := buffer.WriteU8(record.Flags); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteU8.flags: %w", err)
+		}
+		if err := buffer.WriteCharacterString(record.Tag); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteCharacterString.tag: %w", err)
+		}
+		if err := buffer.WriteBytes([]byte(record.Value)); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteBytes.value: %w", err)
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
+	case DSRecord:
+		if err := writeDSRecord(buffer, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeDSRecord: %w", err)
+		}
+
+	case DNSKEYRecord:
+		if err := writeDNSKEYRecord(buffer, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeDNSKEYRecord: %w", err)
+		}
+
+	case RRSIGRecord:
+		if err := writeRRSIGRecord(buffer, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeRRSIGRecord: %w", err)
+		}
+
+	case NSECRecord:
+		if err := writeNSECRecord(buffer, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeNSECRecord: %w", err)
+		}
+
+	case NSEC3Record:
+		if err := writeNSEC3Record(buffer, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeNSEC3Record: %w", err)
+		}
+
+	case NSEC3PARAMRecord:
+		if err := writeNSEC3PARAMRecord(buffer, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeNSEC3PARAMRecord: %w", err)
+		}
+
+	case SVCBRecord:
+		if err := writeSVCBRecord(buffer, SVCB, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeSVCBRecord: %w", err)
+		}
+
+	case HTTPSRecord:
+		if err := writeSVCBRecord(buffer, HTTPS, SVCBRecord(record)); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeSVCBRecord: %w", err)
+		}
+
+	case OPTRecord:
+		if err := writeOPTRecord(buffer, record); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.writeOPTRecord: %w", err)
+		}
+
+	case UnknownRecord:
+		pos, err := writeRecordHeader(buffer, record.Domain, QueryType(record.Type), record.Class, record.TTL)
+		if err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.%w", err)
+		}
+
+		if err := buffer.WriteBytes(record.Data); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.WriteBytes.data: %w", err)
+		}
+
+		if err := closeRecord(buffer, pos); err != nil {
+			return 0, fmt.Errorf("WriteDNSRecord.closeRecord: %w", err)
+		}
+
 	default:
-		fmt.Printf("Skipping record: %+v\n", record)
+		return 0, fmt.Errorf("WriteDNSRecord: unsupported record %T", record)
 	}
 
 	return buffer.Pos() - startPos, nil
@@ -0,0 +1,77 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestReadDNSRecordRDataLengthMismatch(t *testing.T) {
+	// Two A answers for the root; the first one's RDLENGTH disagrees with
+	// the four bytes an address takes.
+	message := func(rdata ...byte) []byte {
+		data := []byte{
+			0x00, 0x01, 0x81, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00,
+			0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2C, 0x00, byte(len(rdata)),
+		}
+		data = append(data, rdata...)
+		return append(data,
+			0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2C, 0x00, 0x04, 0xC0, 0x00, 0x02, 0x02,
+		)
+	}
+	second := ARecord{Domain: "", Class: ClassIN, Addr: net.IPv4(192, 0, 2, 2), TTL: 300}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		first DnsRecord
+	}{
+		{
+			name:  "trailing bytes",
+			data:  message(0xC0, 0x00, 0x02, 0x01, 0xFF),
+			first: ARecord{Domain: "", Class: ClassIN, Addr: net.IPv4(192, 0, 2, 1), TTL: 300},
+		},
+		{
+			name:  "overrun",
+			data:  message(0xC0, 0x00, 0x02),
+			first: UnknownRecord{Domain: "", Class: ClassIN, Type: uint16(A), Data: []byte{0xC0, 0x00, 0x02}, TTL: 300},
+		},
+	}
+
+	for _, tt := range tests {
+		reader := NewBytePacketBufferFrom(tt.data)
+		reader.SetStrict(true)
+		if _, err := NewDNSPacket().Read(reader); !errors.Is(err, ErrRDataLength) {
+			t.Errorf("%s: strict read got %v, want %v", tt.name, err, ErrRDataLength)
+		}
+
+		packet, err := NewDNSPacket().Read(NewBytePacketBufferFrom(tt.data))
+		if err != nil {
+			t.Errorf("%s: lenient read: %v", tt.name, err)
+			continue
+		}
+		if want := []DnsRecord{tt.first, second}; !reflect.DeepEqual(packet.Answers, want) {
+			t.Errorf("%s: answers = %v, want %v", tt.name, packet.Answers, want)
+		}
+	}
+}
+
+func TestWriteDNSRecordShortBuffer(t *testing.T) {
+	for _, record := range testRecords() {
+		full := NewBytePacketBufferSize(MaxTCPPacketSize)
+		size, err := WriteDNSRecord(full, record)
+		if err != nil {
+			t.Errorf("WriteDNSRecord(%v): %v", record, err)
+			continue
+		}
+
+		// Every prefix of the record, including the RDLENGTH placeholder,
+		// must fail to write rather than leave a partial record behind.
+		for limit := uint(0); limit < size; limit++ {
+			if _, err := WriteDNSRecord(NewBytePacketBufferSize(limit), record); err == nil {
+				t.Errorf("WriteDNSRecord(%v) into %d of %d bytes: got no error", record, limit, size)
+			}
+		}
+	}
+}
@@ -0,0 +1,578 @@
+package dns
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// nsec3Encoding is the unpadded base32hex alphabet used for NSEC3 hashed
+// owner names (RFC 5155 section 3.3).
+var nsec3Encoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+type DSRecord struct {
+	Domain     DomainName
+	Class      Class
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     []byte
+	TTL        uint32
+}
+
+func (DSRecord) isDnsRecord() {}
+
+func (record DSRecord) Name() string {
+	return "DS"
+}
+
+func (record DSRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, DS, fmt.Sprintf("%d %d %d %X", record.KeyTag, record.Algorithm, record.DigestType, record.Digest))
+}
+
+type DNSKEYRecord struct {
+	Domain    DomainName
+	Class     Class
+	Flags     uint16
+	Protocol  uint8
+	Algorithm uint8
+	PublicKey []byte
+	TTL       uint32
+}
+
+func (DNSKEYRecord) isDnsRecord() {}
+
+func (record DNSKEYRecord) Name() string {
+	return "DNSKEY"
+}
+
+func (record DNSKEYRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, DNSKEY, fmt.Sprintf("%d %d %d %s", record.Flags, record.Protocol, record.Algorithm, base64.StdEncoding.EncodeToString(record.PublicKey)))
+}
+
+type RRSIGRecord struct {
+	Domain      DomainName
+	Class       Class
+	TypeCovered QueryType
+	Algorithm   uint8
+	Labels      uint8
+	OriginalTTL uint32
+	Expiration  uint32
+	Inception   uint32
+	KeyTag      uint16
+	SignerName  DomainName
+	Signature   []byte
+	TTL         uint32
+}
+
+func (RRSIGRecord) isDnsRecord() {}
+
+func (record RRSIGRecord) Name() string {
+	return "RRSIG"
+}
+
+func (record RRSIGRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, RRSIG, fmt.Sprintf("%s %d %d %d %s %s %d %s %s", record.TypeCovered, record.Algorithm, record.Labels, record.OriginalTTL, formatSignatureTime(record.Expiration), formatSignatureTime(record.Inception), record.KeyTag, fqdn(record.SignerName), base64.StdEncoding.EncodeToString(record.Signature)))
+}
+
+// formatSignatureTime renders an RRSIG timestamp as YYYYMMDDHHmmSS in UTC
+// (RFC 4034 section 3.2).
+func formatSignatureTime(t uint32) string {
+	return time.Unix(int64(t), 0).UTC().Format("20060102150405")
+}
+
+type NSECRecord struct {
+	Domain     DomainName
+	Class      Class
+	NextDomain DomainName
+	Types      []QueryType
+	TTL        uint32
+}
+
+func (NSECRecord) isDnsRecord() {}
+
+func (record NSECRecord) Name() string {
+	return "NSEC"
+}
+
+func (record NSECRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, NSEC, strings.TrimSpace(fmt.Sprintf("%s %s", fqdn(record.NextDomain), formatTypeBitmap(record.Types))))
+}
+
+type NSEC3Record struct {
+	Domain        DomainName
+	Class         Class
+	HashAlgorithm uint8
+	Flags         uint8
+	Iterations    uint16
+	Salt          []byte
+	NextHashed    []byte
+	Types         []QueryType
+	TTL           uint32
+}
+
+func (NSEC3Record) isDnsRecord() {}
+
+func (record NSEC3Record) Name() string {
+	return "NSEC3"
+}
+
+func (record NSEC3Record) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, NSEC3, strings.TrimSpace(fmt.Sprintf("%d %d %d %s %s %s", record.HashAlgorithm, record.Flags, record.Iterations, formatSalt(record.Salt), nsec3Encoding.EncodeToString(record.NextHashed), formatTypeBitmap(record.Types))))
+}
+
+type NSEC3PARAMRecord struct {
+	Domain        DomainName
+	Class         Class
+	HashAlgorithm uint8
+	Flags         uint8
+	Iterations    uint16
+	Salt          []byte
+	TTL           uint32
+}
+
+func (NSEC3PARAMRecord) isDnsRecord() {}
+
+func (record NSEC3PARAMRecord) Name() string {
+	return "NSEC3PARAM"
+}
+
+func (record NSEC3PARAMRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, NSEC3PARAM, fmt.Sprintf("%d %d %d %s", record.HashAlgorithm, record.Flags, record.Iterations, formatSalt(record.Salt)))
+}
+
+func formatSalt(salt []byte) string {
+	if len(salt) == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%X", salt)
+}
+
+func formatTypeBitmap(types []QueryType) string {
+	names := make([]string, 0, len(types))
+	for _, qtype := range types {
+		names = append(names, qtype.String())
+	}
+	return strings.Join(names, " ")
+}
+
+// readTypeBitmap decodes the windowed type bitmap shared by NSEC and NSEC3
+// (RFC 4034 section 4.1.2) up to the end of the RDATA.
+func readTypeBitmap(buffer *BytePacketBuffer, end uint) ([]QueryType, error) {
+	types := make([]QueryType, 0)
+	lastWindow := -1
+
+	for buffer.Pos() < end {
+		window, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("readTypeBitmap.Read.window: %w", err)
+		}
+		if int(window) <= lastWindow {
+			return nil, fmt.Errorf("readTypeBitmap: window %d out of order", window)
+		}
+		lastWindow = int(window)
+
+		length, err := buffer.Read()
+		if err != nil {
+			return nil, fmt.Errorf("readTypeBitmap.Read.length: %w", err)
+		}
+		if length == 0 || length > 32 {
+			return nil, fmt.Errorf("readTypeBitmap: invalid bitmap length %d", length)
+		}
+
+		bitmap, err := buffer.ReadBytes(uint(length))
+		if err != nil {
+			return nil, fmt.Errorf("readTypeBitmap.ReadBytes.bitmap: %w", err)
+		}
+
+		for i, octet := range bitmap {
+			for bit := 0; bit < 8; bit++ {
+				if octet&(0x80>>bit) != 0 {
+					types = append(types, QueryType(uint16(window)<<8|uint16(i*8+bit)))
+				}
+			}
+		}
+	}
+
+	if buffer.Pos() != end {
+		return nil, fmt.Errorf("readTypeBitmap: bitmap overruns RDATA")
+	}
+
+	return types, nil
+}
+
+func writeTypeBitmap(buffer *BytePacketBuffer, types []QueryType) error {
+	sorted := append([]QueryType(nil), types...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for i := 0; i < len(sorted); {
+		window := uint8(sorted[i] >> 8)
+		var bitmap [32]byte
+		length := 0
+
+		for ; i < len(sorted) && uint8(sorted[i]>>8) == window; i++ {
+			low := uint8(sorted[i])
+			bitmap[low/8] |= 0x80 >> (low % 8)
+			length = int(low/8) + 1
+		}
+
+		if err := buffer.WriteU8(window); err != nil {
+			return fmt.Errorf("writeTypeBitmap.WriteU8.window: %w", err)
+		}
+		if err := buffer.WriteU8(uint8(length)); err != nil {
+			return fmt.Errorf("writeTypeBitmap.WriteU8.length: %w", err)
+		}
+		if err := buffer.WriteBytes(bitmap[:length]); err != nil {
+			return fmt.Errorf("writeTypeBitmap.WriteBytes.bitmap: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func readDSRecord(buffer *BytePacketBuffer, domain DomainName, class Class, ttl uint32, dataLength uint16) (DSRecord, error) {
+	start := buffer.Pos()
+	record := DSRecord{Domain: domain, Class: class, TTL: ttl}
+
+	var err error
+	if record.KeyTag, err = buffer.ReadU16(); err != nil {
+		return record, fmt.Errorf("readDSRecord.ReadU16.keyTag: %w", err)
+	}
+	if record.Algorithm, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readDSRecord.Read.algorithm: %w", err)
+	}
+	if record.DigestType, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readDSRecord.Read.digestType: %w", err)
+	}
+
+	left, err := remaining(buffer, start, dataLength)
+	if err != nil {
+		return record, fmt.Errorf("readDSRecord: %w", err)
+	}
+	if record.Digest, err = buffer.ReadBytes(left); err != nil {
+		return record, fmt.Errorf("readDSRecord.ReadBytes.digest: %w", err)
+	}
+
+	return record, nil
+}
+
+func writeDSRecord(buffer *BytePacketBuffer, record DSRecord) error {
+	pos, err := writeRecordHeader(buffer, record.Domain, DS, record.Class, record.TTL)
+	if err != nil {
+		return fmt.Errorf("writeDSRecord.%w", err)
+	}
+
+	if err := buffer.WriteU16(record.KeyTag); err != nil {
+		return fmt.Errorf("writeDSRecord.WriteU16.keyTag: %w", err)
+	}
+	if err := buffer.WriteU8(record.Algorithm); err != nil {
+		return fmt.Errorf("writeDSRecord.WriteU8.algorithm: %w", err)
+	}
+	if err := buffer.WriteU8(record.DigestType); err != nil {
+		return fmt.Errorf("writeDSRecord.WriteU8.digestType: %w", err)
+	}
+	if err := buffer.WriteBytes(record.Digest); err != nil {
+		return fmt.Errorf("writeDSRecord.WriteBytes.digest: %w", err)
+	}
+
+	return closeRecord(buffer, pos)
+}
+
+func readDNSKEYRecord(buffer *BytePacketBuffer, domain DomainName, class Class, ttl uint32, dataLength uint16) (DNSKEYRecord, error) {
+	start := buffer.Pos()
+	record := DNSKEYRecord{Domain: domain, Class: class, TTL: ttl}
+
+	var err error
+	if record.Flags, err = buffer.ReadU16(); err != nil {
+		return record, fmt.Errorf("readDNSKEYRecord.ReadU16.flags: %w", err)
+	}
+	if record.Protocol, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readDNSKEYRecord.Read.protocol: %w", err)
+	}
+	if record.Algorithm, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readDNSKEYRecord.Read.algorithm: %w", err)
+	}
+
+	left, err := remaining(buffer, start, dataLength)
+	if err != nil {
+		return record, fmt.Errorf("readDNSKEYRecord: %w", err)
+	}
+	if record.PublicKey, err = buffer.ReadBytes(left); err != nil {
+		return record, fmt.Errorf("readDNSKEYRecord.ReadBytes.publicKey: %w", err)
+	}
+
+	return record, nil
+}
+
+func writeDNSKEYRecord(buffer *BytePacketBuffer, record DNSKEYRecord) error {
+	pos, err := writeRecordHeader(buffer, record.Domain, DNSKEY, record.Class, record.TTL)
+	if err != nil {
+		return fmt.Errorf("writeDNSKEYRecord.%w", err)
+	}
+
+	if err := buffer.WriteU16(record.Flags); err != nil {
+		return fmt.Errorf("writeDNSKEYRecord.WriteU16.flags: %w", err)
+	}
+	if err := buffer.WriteU8(record.Protocol); err != nil {
+		return fmt.Errorf("writeDNSKEYRecord.WriteU8.protocol: %w", err)
+	}
+	if err := buffer.WriteU8(record.Algorithm); err != nil {
+		return fmt.Errorf("writeDNSKEYRecord.WriteU8.algorithm: %w", err)
+	}
+	if err := buffer.WriteBytes(record.PublicKey); err != nil {
+		return fmt.Errorf("writeDNSKEYRecord.WriteBytes.publicKey: %w", err)
+	}
+
+	return closeRecord(buffer, pos)
+}
+
+func readRRSIGRecord(buffer *BytePacketBuffer, domain DomainName, class Class, ttl uint32, dataLength uint16) (RRSIGRecord, error) {
+	start := buffer.Pos()
+	record := RRSIGRecord{Domain: domain, Class: class, TTL: ttl}
+
+	typeCovered, err := buffer.ReadU16()
+	if err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.ReadU16.typeCovered: %w", err)
+	}
+	record.TypeCovered = QueryType(typeCovered)
+
+	if record.Algorithm, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.Read.algorithm: %w", err)
+	}
+	if record.Labels, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.Read.labels: %w", err)
+	}
+	if record.OriginalTTL, err = buffer.ReadU32(); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.ReadU32.originalTTL: %w", err)
+	}
+	if record.Expiration, err = buffer.ReadU32(); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.ReadU32.expiration: %w", err)
+	}
+	if record.Inception, err = buffer.ReadU32(); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.ReadU32.inception: %w", err)
+	}
+	if record.KeyTag, err = buffer.ReadU16(); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.ReadU16.keyTag: %w", err)
+	}
+	if err := buffer.ReadQName(&record.SignerName); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.ReadQName.signerName: %w", err)
+	}
+
+	left, err := remaining(buffer, start, dataLength)
+	if err != nil {
+		return record, fmt.Errorf("readRRSIGRecord: %w", err)
+	}
+	if record.Signature, err = buffer.ReadBytes(left); err != nil {
+		return record, fmt.Errorf("readRRSIGRecord.ReadBytes.signature: %w", err)
+	}
+
+	return record, nil
+}
+
+func writeRRSIGRecord(buffer *BytePacketBuffer, record RRSIGRecord) error {
+	pos, err := writeRecordHeader(buffer, record.Domain, RRSIG, record.Class, record.TTL)
+	if err != nil {
+		return fmt.Errorf("writeRRSIGRecord.%w", err)
+	}
+
+	if err := buffer.WriteU16(uint16(record.TypeCovered)); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteU16.typeCovered: %w", err)
+	}
+	if err := buffer.WriteU8(record.Algorithm); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteU8.algorithm: %w", err)
+	}
+	if err := buffer.WriteU8(record.Labels); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteU8.labels: %w", err)
+	}
+	if err := buffer.WriteU32(record.OriginalTTL); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteU32.originalTTL: %w", err)
+	}
+	if err := buffer.WriteU32(record.Expiration); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteU32.expiration: %w", err)
+	}
+	if err := buffer.WriteU32(record.Inception); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteU32.inception: %w", err)
+	}
+	if err := buffer.WriteU16(record.KeyTag); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteU16.keyTag: %w", err)
+	}
+	// RFC 4034 section 3.1.7: the signer's name must not be compressed.
+	if err := buffer.WriteQNameUncompressed(&record.SignerName); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteQNameUncompressed.signerName: %w", err)
+	}
+	if err := buffer.WriteBytes(record.Signature); err != nil {
+		return fmt.Errorf("writeRRSIGRecord.WriteBytes.signature: %w", err)
+	}
+
+	return closeRecord(buffer, pos)
+}
+
+func readNSECRecord(buffer *BytePacketBuffer, domain DomainName, class Class, ttl uint32, dataLength uint16) (NSECRecord, error) {
+	start := buffer.Pos()
+	record := NSECRecord{Domain: domain, Class: class, TTL: ttl}
+
+	if err := buffer.ReadQName(&record.NextDomain); err != nil {
+		return record, fmt.Errorf("readNSECRecord.ReadQName.nextDomain: %w", err)
+	}
+
+	types, err := readTypeBitmap(buffer, start+uint(dataLength))
+	if err != nil {
+		return record, fmt.Errorf("readNSECRecord.%w", err)
+	}
+	record.Types = types
+
+	return record, nil
+}
+
+func writeNSECRecord(buffer *BytePacketBuffer, record NSECRecord) error {
+	pos, err := writeRecordHeader(buffer, record.Domain, NSEC, record.Class, record.TTL)
+	if err != nil {
+		return fmt.Errorf("writeNSECRecord.%w", err)
+	}
+
+	// RFC 4034 section 4.1.1: the next domain name must not be compressed.
+	if err := buffer.WriteQNameUncompressed(&record.NextDomain); err != nil {
+		return fmt.Errorf("writeNSECRecord.WriteQNameUncompressed.nextDomain: %w", err)
+	}
+	if err := writeTypeBitmap(buffer, record.Types); err != nil {
+		return fmt.Errorf("writeNSECRecord.%w", err)
+	}
+
+	return closeRecord(buffer, pos)
+}
+
+func readNSEC3Record(buffer *BytePacketBuffer, domain DomainName, class Class, ttl uint32, dataLength uint16) (NSEC3Record, error) {
+	start := buffer.Pos()
+	record := NSEC3Record{Domain: domain, Class: class, TTL: ttl}
+
+	var err error
+	if record.HashAlgorithm, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readNSEC3Record.Read.hashAlgorithm: %w", err)
+	}
+	if record.Flags, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readNSEC3Record.Read.flags: %w", err)
+	}
+	if record.Iterations, err = buffer.ReadU16(); err != nil {
+		return record, fmt.Errorf("readNSEC3Record.ReadU16.iterations: %w", err)
+	}
+
+	saltLength, err := buffer.Read()
+	if err != nil {
+		return record, fmt.Errorf("readNSEC3Record.Read.saltLength: %w", err)
+	}
+	if record.Salt, err = buffer.ReadBytes(uint(saltLength)); err != nil {
+		return record, fmt.Errorf("readNSEC3Record.ReadBytes.salt: %w", err)
+	}
+
+	hashLength, err := buffer.Read()
+	if err != nil {
+		return record, fmt.Errorf("readNSEC3Record.Read.hashLength: %w", err)
+	}
+	if record.NextHashed, err = buffer.ReadBytes(uint(hashLength)); err != nil {
+		return record, fmt.Errorf("readNSEC3Record.ReadBytes.nextHashed: %w", err)
+	}
+
+	if _, err := remaining(buffer, start, dataLength); err != nil {
+		return record, fmt.Errorf("readNSEC3Record: %w", err)
+	}
+	if record.Types, err = readTypeBitmap(buffer, start+uint(dataLength)); err != nil {
+		return record, fmt.Errorf("readNSEC3Record.%w", err)
+	}
+
+	return record, nil
+}
+
+func writeNSEC3Record(buffer *BytePacketBuffer, record NSEC3Record) error {
+	if len(record.Salt) > 0xFF || len(record.NextHashed) > 0xFF {
+		return fmt.Errorf("writeNSEC3Record: salt or hash exceeds 255 bytes")
+	}
+
+	pos, err := writeRecordHeader(buffer, record.Domain, NSEC3, record.Class, record.TTL)
+	if err != nil {
+		return fmt.Errorf("writeNSEC3Record.%w", err)
+	}
+
+	if err := buffer.WriteU8(record.HashAlgorithm); err != nil {
+		return fmt.Errorf("writeNSEC3Record.WriteU8.hashAlgorithm: %w", err)
+	}
+	if err := buffer.WriteU8(record.Flags); err != nil {
+		return fmt.Errorf("writeNSEC3Record.WriteU8.flags: %w", err)
+	}
+	if err := buffer.WriteU16(record.Iterations); err != nil {
+		return fmt.Errorf("writeNSEC3Record.WriteU16.iterations: %w", err)
+	}
+	if err := buffer.WriteU8(uint8(len(record.Salt))); err != nil {
+		return fmt.Errorf("writeNSEC3Record.WriteU8.saltLength: %w", err)
+	}
+	if err := buffer.WriteBytes(record.Salt); err != nil {
+		return fmt.Errorf("writeNSEC3Record.WriteBytes.salt: %w", err)
+	}
+	if err := buffer.WriteU8(uint8(len(record.NextHashed))); err != nil {
+		return fmt.Errorf("writeNSEC3Record.WriteU8.hashLength: %w", err)
+	}
+	if err := buffer.WriteBytes(record.NextHashed); err != nil {
+		return fmt.Errorf("writeNSEC3Record.WriteBytes.nextHashed: %w", err)
+	}
+	if err := writeTypeBitmap(buffer, record.Types); err != nil {
+		return fmt.Errorf("writeNSEC3Record.%w", err)
+	}
+
+	return closeRecord(buffer, pos)
+}
+
+func readNSEC3PARAMRecord(buffer *BytePacketBuffer, domain DomainName, class Class, ttl uint32) (NSEC3PARAMRecord, error) {
+	record := NSEC3PARAMRecord{Domain: domain, Class: class, TTL: ttl}
+
+	var err error
+	if record.HashAlgorithm, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readNSEC3PARAMRecord.Read.hashAlgorithm: %w", err)
+	}
+	if record.Flags, err = buffer.Read(); err != nil {
+		return record, fmt.Errorf("readNSEC3PARAMRecord.Read.flags: %w", err)
+	}
+	if record.Iterations, err = buffer.ReadU16(); err != nil {
+		return record, fmt.Errorf("readNSEC3PARAMRecord.ReadU16.iterations: %w", err)
+	}
+
+	saltLength, err := buffer.Read()
+	if err != nil {
+		return record, fmt.Errorf("readNSEC3PARAMRecord.Read.saltLength: %w", err)
+	}
+	if record.Salt, err = buffer.ReadBytes(uint(saltLength)); err != nil {
+		return record, fmt.Errorf("readNSEC3PARAMRecord.ReadBytes.salt: %w", err)
+	}
+
+	return record, nil
+}
+
+func writeNSEC3PARAMRecord(buffer *BytePacketBuffer, record NSEC3PARAMRecord) error {
+	if len(record.Salt) > 0xFF {
+		return fmt.Errorf("writeNSEC3PARAMRecord: salt exceeds 255 bytes")
+	}
+
+	pos, err := writeRecordHeader(buffer, record.Domain, NSEC3PARAM, record.Class, record.TTL)
+	if err != nil {
+		return fmt.Errorf("writeNSEC3PARAMRecord.%w", err)
+	}
+
+	if err := buffer.WriteU8(record.HashAlgorithm); err != nil {
+		return fmt.Errorf("writeNSEC3PARAMRecord.WriteU8.hashAlgorithm: %w", err)
+	}
+	if err := buffer.WriteU8(record.Flags); err != nil {
+		return fmt.Errorf("writeNSEC3PARAMRecord.WriteU8.flags: %w", err)
+	}
+	if err := buffer.WriteU16(record.Iterations); err != nil {
+		return fmt.Errorf("writeNSEC3PARAMRecord.WriteU16.iterations: %w", err)
+	}
+	if err := buffer.WriteU8(uint8(len(record.Salt))); err != nil {
+		return fmt.Errorf("writeNSEC3PARAMRecord.WriteU8.saltLength: %w", err)
+	}
+	if err := buffer.WriteBytes(record.Salt); err != nil {
+		return fmt.Errorf("writeNSEC3PARAMRecord.WriteBytes.salt: %w", err)
+	}
+
+	return closeRecord(buffer, pos)
+}
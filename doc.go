@@ -0,0 +1,6 @@
+// Package dns encodes and decodes DNS messages: the wire format with name
+// compression, EDNS0, the record types under RFC 1035 and its successors,
+// zone-file presentation format and RFC 8427 JSON.
+//
+// The forwarding server built on it lives in cmd/simple-dns.
+package dns // import "github.com/Sannrox/simple-dns"
@@ -0,0 +1,201 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DomainName is a domain name in presentation format (RFC 1035 section
+// 5.1): labels separated by dots, with "\." for a dot inside a label and
+// "\DDD" or "\X" for any other byte that needs escaping. Names read off the
+// wire carry no trailing dot and the root is the empty name.
+//
+// Comparisons ignore ASCII case as RFC 4343 requires and work label by
+// label, so "foo.badexample.com" is not below "example.com".
+type DomainName string
+
+// DomainNameFromLabels joins raw labels into a name, escaping any byte that
+// would otherwise be read as syntax.
+func DomainNameFromLabels(labels []string) DomainName {
+	escaped := make([]string, 0, len(labels))
+	for _, label := range labels {
+		escaped = append(escaped, escapeLabel(label))
+	}
+	return DomainName(strings.Join(escaped, "."))
+}
+
+// escapeLabel renders a raw label for presentation format.
+func escapeLabel(label string) string {
+	var builder strings.Builder
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		switch {
+		case c == '.' || c == '\\' || c == '"' || c == '(' || c == ')' || c == ';' || c == '@' || c == '$':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case c < 0x21 || c > 0x7E:
+			fmt.Fprintf(&builder, "\\%03d", c)
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// splitLabels resolves escapes and splits s on unescaped dots. It always
+// returns its best reading of s; the error reports what was malformed.
+func splitLabels(s string) ([]string, error) {
+	labels := make([]string, 0)
+	if s == "" || s == "." {
+		return labels, nil
+	}
+
+	var label []byte
+	var err error
+	dotted := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		dotted = false
+		switch {
+		case c == '.':
+			if len(label) == 0 && err == nil {
+				err = fmt.Errorf("empty label in %q", s)
+			}
+			labels = append(labels, string(label))
+			label = label[:0]
+			dotted = true
+		case c != '\\':
+			label = append(label, c)
+		case i+3 < len(s) && isDigit(s[i+1]) && isDigit(s[i+2]) && isDigit(s[i+3]):
+			value := int(s[i+1]-'0')*100 + int(s[i+2]-'0')*10 + int(s[i+3]-'0')
+			if value > 0xFF && err == nil {
+				err = fmt.Errorf("escape \\%s out of range in %q", s[i+1:i+4], s)
+			}
+			label = append(label, byte(value))
+			i += 3
+		case i+1 < len(s):
+			if isDigit(s[i+1]) && err == nil {
+				err = fmt.Errorf("short \\DDD escape in %q", s)
+			}
+			label = append(label, s[i+1])
+			i++
+		default:
+			if err == nil {
+				err = fmt.Errorf("trailing backslash in %q", s)
+			}
+			label = append(label, c)
+		}
+	}
+
+	if !dotted {
+		labels = append(labels, string(label))
+	}
+	return labels, err
+}
+
+// Labels returns the raw, unescaped labels of n, leftmost first. The root
+// has none.
+func (n DomainName) Labels() ([]string, error) {
+	labels, err := splitLabels(string(n))
+	if err != nil {
+		return nil, fmt.Errorf("DomainName.Labels: %s", err)
+	}
+	return labels, nil
+}
+
+// CountLabels returns the number of labels in n, not counting the root.
+func (n DomainName) CountLabels() int {
+	labels, _ := splitLabels(string(n))
+	return len(labels)
+}
+
+// IsFQDN reports whether n ends in an unescaped dot.
+func (n DomainName) IsFQDN() bool {
+	s := string(n)
+	if !strings.HasSuffix(s, ".") {
+		return false
+	}
+
+	// The dot is escaped only if an odd number of backslashes precede it.
+	backslashes := 0
+	for i := len(s) - 2; i >= 0 && s[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 0
+}
+
+// WireLength is the length of n once encoded without compression.
+func (n DomainName) WireLength() int {
+	labels, _ := splitLabels(string(n))
+	length := 1
+	for _, label := range labels {
+		length += len(label) + 1
+	}
+	return length
+}
+
+// Canonical returns n in the lower-case form of RFC 4034 section 6.2,
+// without a trailing dot.
+func (n DomainName) Canonical() DomainName {
+	labels, _ := splitLabels(string(n))
+	for i, label := range labels {
+		labels[i] = lowerASCII(label)
+	}
+	return DomainNameFromLabels(labels)
+}
+
+// Equal reports whether n and other name the same node, ignoring ASCII case.
+func (n DomainName) Equal(other DomainName) bool {
+	return n.Compare(other) == 0
+}
+
+// IsSubdomainOf reports whether n is parent or lies below it.
+func (n DomainName) IsSubdomainOf(parent DomainName) bool {
+	child, _ := splitLabels(string(n))
+	ancestor, _ := splitLabels(string(parent))
+	if len(ancestor) > len(child) {
+		return false
+	}
+
+	offset := len(child) - len(ancestor)
+	for i, label := range ancestor {
+		if lowerASCII(label) != lowerASCII(child[offset+i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders names canonically (RFC 4034 section 6.1): label by label
+// from the root, each compared as lower-cased octets. It returns -1, 0 or 1.
+func (n DomainName) Compare(other DomainName) int {
+	left, _ := splitLabels(string(n))
+	right, _ := splitLabels(string(other))
+
+	for i, j := len(left)-1, len(right)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(lowerASCII(left[i]), lowerASCII(right[j])); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(left) < len(right):
+		return -1
+	case len(left) > len(right):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// lowerASCII folds A-Z only; RFC 4343 leaves every other octet alone.
+func lowerASCII(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
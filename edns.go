@@ -0,0 +1,184 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+)
+
+type EDNSOptionCode uint16
+
+const (
+	EDNSOptionLLQ          EDNSOptionCode = 1
+	EDNSOptionNSID         EDNSOptionCode = 3
+	EDNSOptionClientSubnet EDNSOptionCode = 8
+	EDNSOptionExpire       EDNSOptionCode = 9
+	EDNSOptionCookie       EDNSOptionCode = 10
+	EDNSOptionKeepalive    EDNSOptionCode = 11
+	EDNSOptionPadding      EDNSOptionCode = 12
+	EDNSOptionChain        EDNSOptionCode = 13
+	EDNSOptionKeyTag       EDNSOptionCode = 14
+	EDNSOptionExtendedErr  EDNSOptionCode = 15
+)
+
+func (code EDNSOptionCode) String() string {
+	switch code {
+	case EDNSOptionLLQ:
+		return "LLQ"
+	case EDNSOptionNSID:
+		return "NSID"
+	case EDNSOptionClientSubnet:
+		return "ECS"
+	case EDNSOptionExpire:
+		return "EXPIRE"
+	case EDNSOptionCookie:
+		return "COOKIE"
+	case EDNSOptionKeepalive:
+		return "KEEPALIVE"
+	case EDNSOptionPadding:
+		return "PADDING"
+	case EDNSOptionChain:
+		return "CHAIN"
+	case EDNSOptionKeyTag:
+		return "KEY-TAG"
+	case EDNSOptionExtendedErr:
+		return "EDE"
+	default:
+		return fmt.Sprintf("OPT%d", uint16(code))
+	}
+}
+
+type EDNSOption struct {
+	Code EDNSOptionCode
+	Data []byte
+}
+
+func (option EDNSOption) String() string {
+	return fmt.Sprintf("%s: %x", option.Code, option.Data)
+}
+
+// OPTRecord is the EDNS0 pseudo-record from RFC 6891. Its CLASS carries the
+// sender's UDP payload size and its TTL carries the extended RCODE, the
+// EDNS version and the DO flag.
+type OPTRecord struct {
+	UDPSize       uint16
+	ExtendedRCode uint8
+	Version       uint8
+	DO            bool
+	Z             uint16
+	Options       []EDNSOption
+}
+
+func NewOPTRecord(udpSize uint16, dnssecOK bool) OPTRecord {
+	return OPTRecord{
+		UDPSize: udpSize,
+		DO:      dnssecOK,
+		Options: make([]EDNSOption, 0),
+	}
+}
+
+func (OPTRecord) isDnsRecord() {}
+
+func (record OPTRecord) Name() string {
+	return "OPT"
+}
+
+func (record OPTRecord) String() string {
+	options := make([]string, 0, len(record.Options))
+	for _, option := range record.Options {
+		options = append(options, option.String())
+	}
+	return fmt.Sprintf("version: %d, do: %t, udp: %d, ercode: %d, options: [%s]", record.Version, record.DO, record.UDPSize, record.ExtendedRCode, strings.Join(options, ", "))
+}
+
+// PayloadSize returns the UDP payload size the sender can receive. Values
+// below 512 are treated as 512, as RFC 6891 section 6.2.3 requires.
+func (record OPTRecord) PayloadSize() uint {
+	if record.UDPSize < MaxUDPPacketSize {
+		return MaxUDPPacketSize
+	}
+	return uint(record.UDPSize)
+}
+
+func (record OPTRecord) ttl() uint32 {
+	ttl := uint32(record.ExtendedRCode)<<24 | uint32(record.Version)<<16 | uint32(record.Z&0x7FFF)
+	if record.DO {
+		ttl |= 1 << 15
+	}
+	return ttl
+}
+
+func readOPTRecord(buffer *BytePacketBuffer, class uint16, ttl uint32, dataLength uint16) (OPTRecord, error) {
+	record := OPTRecord{
+		UDPSize:       class,
+		ExtendedRCode: uint8(ttl >> 24),
+		Version:       uint8(ttl >> 16),
+		DO:            ttl&(1<<15) > 0,
+		Z:             uint16(ttl & 0x7FFF),
+		Options:       make([]EDNSOption, 0),
+	}
+
+	end := buffer.Pos() + uint(dataLength)
+	for buffer.Pos() < end {
+		code, err := buffer.ReadU16()
+		if err != nil {
+			return record, fmt.Errorf("readOPTRecord.ReadU16.code: %w", err)
+		}
+		length, err := buffer.ReadU16()
+		if err != nil {
+			return record, fmt.Errorf("readOPTRecord.ReadU16.length: %w", err)
+		}
+		data, err := buffer.ReadBytes(uint(length))
+		if err != nil {
+			return record, fmt.Errorf("readOPTRecord.ReadBytes.data: %w", err)
+		}
+
+		record.Options = append(record.Options, EDNSOption{
+			Code: EDNSOptionCode(code),
+			Data: data,
+		})
+	}
+
+	if buffer.Pos() != end {
+		return record, fmt.Errorf("readOPTRecord: options overrun RDATA by %d bytes", buffer.Pos()-end)
+	}
+
+	return record, nil
+}
+
+func writeOPTRecord(buffer *BytePacketBuffer, record OPTRecord) error {
+	if err := buffer.WriteU8(0); err != nil {
+		return fmt.Errorf("writeOPTRecord.WriteU8.domain: %w", err)
+	}
+
+	if err := buffer.WriteU16(uint16(OPT)); err != nil {
+		return fmt.Errorf("writeOPTRecord.WriteU16.qtype: %w", err)
+	}
+
+	if err := buffer.WriteU16(record.UDPSize); err != nil {
+		return fmt.Errorf("writeOPTRecord.WriteU16.udpSize: %w", err)
+	}
+
+	if err := buffer.WriteU32(record.ttl()); err != nil {
+		return fmt.Errorf("writeOPTRecord.WriteU32.ttl: %w", err)
+	}
+
+	pos := buffer.Pos()
+	if err := buffer.WriteU16(0); err != nil {
+		return fmt.Errorf("writeOPTRecord.WriteU16.dataLength: %w", err)
+	}
+
+	for _, option := range record.Options {
+		if err := buffer.WriteU16(uint16(option.Code)); err != nil {
+			return fmt.Errorf("writeOPTRecord.WriteU16.code: %w", err)
+		}
+		if err := buffer.WriteU16(uint16(len(option.Data))); err != nil {
+			return fmt.Errorf("writeOPTRecord.WriteU16.length: %w", err)
+		}
+		if err := buffer.WriteBytes(option.Data); err != nil {
+			return fmt.Errorf("writeOPTRecord.WriteBytes.data: %w", err)
+		}
+	}
+
+	size := buffer.Pos() - (pos + 2)
+	return buffer.SetU16(pos, uint16(size))
+}
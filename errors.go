@@ -0,0 +1,37 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned while decoding a malformed message. They are wrapped, so
+// test for them with errors.Is. ErrEndOfBuffer is also returned by writes
+// that would exceed the buffer's limit.
+var (
+	ErrEndOfBuffer   = errors.New("end of buffer")
+	ErrNameTooLong   = errors.New("name exceeds 255 bytes")
+	ErrLabelType     = errors.New("reserved label type")
+	ErrBadPointer    = errors.New("compression pointer does not point backwards")
+	ErrPointerLoop   = errors.New("too many compression pointers")
+	ErrRDataLength   = errors.New("RDATA does not match RDLENGTH")
+	ErrSectionCount  = errors.New("section counts exceed message size")
+	ErrTrailingBytes = errors.New("trailing bytes after last record")
+	ErrBadOPT        = errors.New("malformed OPT record")
+)
+
+// FormatError is returned by DNSPacket.Read for any message that cannot be
+// decoded. A server answers such a query with FORMERR.
+type FormatError struct {
+	// Offset is the buffer position where decoding stopped.
+	Offset uint
+	Err    error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("format error at offset %d: %s", e.Offset, e.Err)
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
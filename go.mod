@@ -1,3 +1,3 @@
-module dns
+module github.com/Sannrox/simple-dns
 
 go 1.20
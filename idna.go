@@ -0,0 +1,428 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// acePrefix marks an A-label (RFC 5890 section 2.3.2.1).
+const acePrefix = "xn--"
+
+// Bootstring parameters for Punycode (RFC 3492 section 5).
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// ToASCII converts a name typed by a user into its IDNA2008 form: every
+// label holding non-ASCII characters is lower-cased (the mapping of RFC
+// 5895), validated and replaced by its A-label. ASCII labels are kept as
+// they are, except that existing A-labels must decode. The ideographic full
+// stops U+3002, U+FF0E and U+FF61 separate labels like ".".
+//
+// Validation follows the rules of RFC 5891 section 4.2 that can be decided
+// from Unicode categories alone; input is expected to be in NFC already and
+// the Bidi rule of RFC 5893 is not applied.
+func ToASCII(name string) (DomainName, error) {
+	name = strings.NewReplacer("。", ".", "．", ".", "｡", ".").Replace(name)
+
+	segments := splitPresentation(name)
+	for i, segment := range segments {
+		if isASCII(segment) {
+			if hasACEPrefix(segment) {
+				if _, err := decodeALabel(segment); err != nil {
+					return "", fmt.Errorf("ToASCII: %s", err)
+				}
+			}
+			continue
+		}
+
+		labels, err := splitLabels(segment)
+		if err != nil || len(labels) != 1 {
+			return "", fmt.Errorf("ToASCII: malformed label %q", segment)
+		}
+		label := strings.ToLower(labels[0])
+		if err := validateULabel(label); err != nil {
+			return "", fmt.Errorf("ToASCII: %s", err)
+		}
+
+		encoded, err := punycodeEncode(label)
+		if err != nil {
+			return "", fmt.Errorf("ToASCII: %s", err)
+		}
+		if len(acePrefix)+len(encoded) > 63 {
+			return "", fmt.Errorf("ToASCII: A-label for %q exceeds 63 characters", label)
+		}
+		segments[i] = acePrefix + encoded
+	}
+
+	return DomainName(strings.Join(segments, ".")), nil
+}
+
+// ToUnicode returns n with every A-label replaced by its U-label. Other
+// labels keep their presentation form, escapes included.
+func (n DomainName) ToUnicode() (string, error) {
+	segments := splitPresentation(string(n))
+	for i, segment := range segments {
+		if !hasACEPrefix(segment) {
+			continue
+		}
+
+		label, err := decodeALabel(segment)
+		if err != nil {
+			return "", fmt.Errorf("DomainName.ToUnicode: %s", err)
+		}
+		segments[i] = label
+	}
+
+	return strings.Join(segments, "."), nil
+}
+
+// StringUnicode renders n with a trailing dot like the String methods do,
+// but shows A-labels as U-labels, e.g. "bücher.example." instead of
+// "xn--bcher-kva.example.". A name whose A-labels do not decode is shown
+// unchanged.
+func (n DomainName) StringUnicode() string {
+	return fqdn(n.unicodeOrSelf())
+}
+
+func (n DomainName) unicodeOrSelf() DomainName {
+	if unicode, err := n.ToUnicode(); err == nil {
+		return DomainName(unicode)
+	}
+	return n
+}
+
+// StringUnicode is String with the name shown as by DomainName.StringUnicode.
+func (q *DNSQuestion) StringUnicode() string {
+	unicode := *q
+	unicode.Name = q.Name.unicodeOrSelf()
+	return unicode.String()
+}
+
+// RecordStringUnicode is record.String() with every domain name in the
+// record shown as by DomainName.StringUnicode.
+func RecordStringUnicode(record DnsRecord) string {
+	switch record := record.(type) {
+	case UnknownRecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case ARecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case NSRecord:
+		record.Domain, record.Host = record.Domain.unicodeOrSelf(), record.Host.unicodeOrSelf()
+		return record.String()
+	case CNameRecord:
+		record.Domain, record.Host = record.Domain.unicodeOrSelf(), record.Host.unicodeOrSelf()
+		return record.String()
+	case SOARecord:
+		record.Domain, record.MName, record.RName = record.Domain.unicodeOrSelf(), record.MName.unicodeOrSelf(), record.RName.unicodeOrSelf()
+		return record.String()
+	case PTRRecord:
+		record.Domain, record.Host = record.Domain.unicodeOrSelf(), record.Host.unicodeOrSelf()
+		return record.String()
+	case DNAMERecord:
+		record.Domain, record.Target = record.Domain.unicodeOrSelf(), record.Target.unicodeOrSelf()
+		return record.String()
+	case MXRecord:
+		record.Domain, record.Host = record.Domain.unicodeOrSelf(), record.Host.unicodeOrSelf()
+		return record.String()
+	case AAAARecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case TXTRecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case SRVRecord:
+		record.Domain, record.Target = record.Domain.unicodeOrSelf(), record.Target.unicodeOrSelf()
+		return record.String()
+	case NAPTRRecord:
+		record.Domain, record.Replacement = record.Domain.unicodeOrSelf(), record.Replacement.unicodeOrSelf()
+		return record.String()
+	case SSHFPRecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case TLSARecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case CAARecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case DSRecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case DNSKEYRecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case RRSIGRecord:
+		record.Domain, record.SignerName = record.Domain.unicodeOrSelf(), record.SignerName.unicodeOrSelf()
+		return record.String()
+	case NSECRecord:
+		record.Domain, record.NextDomain = record.Domain.unicodeOrSelf(), record.NextDomain.unicodeOrSelf()
+		return record.String()
+	case NSEC3Record:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case NSEC3PARAMRecord:
+		record.Domain = record.Domain.unicodeOrSelf()
+		return record.String()
+	case SVCBRecord:
+		record.Domain, record.Target = record.Domain.unicodeOrSelf(), record.Target.unicodeOrSelf()
+		return record.String()
+	case HTTPSRecord:
+		record.Domain, record.Target = record.Domain.unicodeOrSelf(), record.Target.unicodeOrSelf()
+		return record.String()
+	default:
+		return record.String()
+	}
+}
+
+// splitPresentation splits s on unescaped dots without resolving escapes. A
+// trailing dot yields a final empty segment, so joining restores it.
+func splitPresentation(s string) []string {
+	segments := make([]string, 0)
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '.':
+			segments = append(segments, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, s[start:])
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func hasACEPrefix(label string) bool {
+	return len(label) >= len(acePrefix) && strings.EqualFold(label[:len(acePrefix)], acePrefix)
+}
+
+// decodeALabel turns an A-label into its U-label, checking that the result
+// is a valid U-label that encodes back to the same A-label.
+func decodeALabel(label string) (string, error) {
+	lower := strings.ToLower(label)
+	decoded, err := punycodeDecode(lower[len(acePrefix):])
+	if err != nil {
+		return "", fmt.Errorf("invalid A-label %q: %s", label, err)
+	}
+	if isASCII(decoded) {
+		return "", fmt.Errorf("invalid A-label %q: decodes to ASCII", label)
+	}
+	if err := validateULabel(decoded); err != nil {
+		return "", fmt.Errorf("invalid A-label %q: %s", label, err)
+	}
+	if encoded, err := punycodeEncode(decoded); err != nil || acePrefix+encoded != lower {
+		return "", fmt.Errorf("invalid A-label %q: does not round-trip", label)
+	}
+	return decoded, nil
+}
+
+// validateULabel applies the IDNA2008 label rules: no hyphen at either end
+// or in the third and fourth positions, no leading combining mark, and only
+// lower-case letters, marks, decimal digits and hyphens.
+func validateULabel(label string) error {
+	if label == "" {
+		return fmt.Errorf("empty label")
+	}
+	if !utf8.ValidString(label) {
+		return fmt.Errorf("label %q is not valid UTF-8", label)
+	}
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return fmt.Errorf("label %q starts or ends with a hyphen", label)
+	}
+	if runes := []rune(label); len(runes) >= 4 && runes[2] == '-' && runes[3] == '-' {
+		return fmt.Errorf("label %q has hyphens in the third and fourth positions", label)
+	}
+
+	for i, r := range label {
+		switch {
+		case i == 0 && unicode.Is(unicode.M, r):
+			return fmt.Errorf("label %q starts with a combining mark", label)
+		case r == '-', unicode.Is(unicode.Nd, r), unicode.Is(unicode.M, r):
+		case unicode.IsLetter(r) && !unicode.IsUpper(r) && !unicode.IsTitle(r):
+		default:
+			return fmt.Errorf("label %q contains disallowed character %U", label, r)
+		}
+	}
+	return nil
+}
+
+func punycodeAdapt(delta, numPoints int32, first bool) int32 {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+
+	k := int32(0)
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+func punycodeThreshold(k, bias int32) int32 {
+	switch {
+	case k <= bias:
+		return punyTMin
+	case k >= bias+punyTMax:
+		return punyTMax
+	default:
+		return k - bias
+	}
+}
+
+func punycodeDigit(d int32) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+// punycodeEncode implements the encoding procedure of RFC 3492 section 6.3.
+func punycodeEncode(s string) (string, error) {
+	input := []rune(s)
+	output := make([]byte, 0, len(s)+8)
+	for _, r := range input {
+		if r < utf8.RuneSelf {
+			output = append(output, byte(r))
+		}
+	}
+
+	basic := int32(len(output))
+	handled := basic
+	if basic > 0 {
+		output = append(output, '-')
+	}
+
+	n, delta, bias := int32(punyInitialN), int32(0), int32(punyInitialBias)
+	for handled < int32(len(input)) {
+		m := int32(unicode.MaxRune + 1)
+		for _, r := range input {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+
+		if (m - n) > (1<<31-1-delta)/(handled+1) {
+			return "", fmt.Errorf("punycode overflow")
+		}
+		delta += (m - n) * (handled + 1)
+		n = m
+
+		for _, r := range input {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+
+			q := delta
+			for k := int32(punyBase); ; k += punyBase {
+				t := punycodeThreshold(k, bias)
+				if q < t {
+					break
+				}
+				output = append(output, punycodeDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			output = append(output, punycodeDigit(q))
+
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+
+	return string(output), nil
+}
+
+// punycodeDecode implements the decoding procedure of RFC 3492 section 6.2.
+func punycodeDecode(s string) (string, error) {
+	output := make([]rune, 0, len(s))
+	pos := 0
+	if delimiter := strings.LastIndexByte(s, '-'); delimiter >= 0 {
+		for _, c := range []byte(s[:delimiter]) {
+			if c >= utf8.RuneSelf {
+				return "", fmt.Errorf("non-basic code point before delimiter")
+			}
+			output = append(output, rune(c))
+		}
+		pos = delimiter + 1
+	}
+
+	n, i, bias := int32(punyInitialN), int32(0), int32(punyInitialBias)
+	for pos < len(s) {
+		oldI, w := i, int32(1)
+		for k := int32(punyBase); ; k += punyBase {
+			if pos >= len(s) {
+				return "", fmt.Errorf("truncated input")
+			}
+
+			var digit int32
+			switch c := s[pos]; {
+			case c >= 'a' && c <= 'z':
+				digit = int32(c - 'a')
+			case c >= 'A' && c <= 'Z':
+				digit = int32(c - 'A')
+			case c >= '0' && c <= '9':
+				digit = int32(c-'0') + 26
+			default:
+				return "", fmt.Errorf("invalid digit %q", c)
+			}
+			pos++
+
+			if digit > (1<<31-1-i)/w {
+				return "", fmt.Errorf("punycode overflow")
+			}
+			i += digit * w
+
+			t := punycodeThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			if w > (1<<31-1)/(punyBase-t) {
+				return "", fmt.Errorf("punycode overflow")
+			}
+			w *= punyBase - t
+		}
+
+		length := int32(len(output)) + 1
+		bias = punycodeAdapt(i-oldI, length, oldI == 0)
+		if i/length > unicode.MaxRune-n {
+			return "", fmt.Errorf("punycode overflow")
+		}
+		n += i / length
+		i %= length
+
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = n
+		i++
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,115 @@
+package dns
+
+import (
+	"testing"
+)
+
+// Sample strings from RFC 3492 section 7.1.
+var punycodeTests = []struct {
+	name    string
+	decoded string
+	encoded string
+}{
+	{"(A) Arabic (Egyptian)", "ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+	{"(B) Chinese (simplified)", "他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+	{"(C) Chinese (traditional)", "他們爲什麽不說中文", "ihqwctvzc91f659drss3x8bo0yb"},
+	{"(D) Czech", "Pročprostěnemluvíčesky", "Proprostnemluvesky-uyb24dma41a"},
+	{"(I) Russian", "почемужеонинеговорятпорусски", "b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+	{"(L) 3<nen>B<gumi><kinpachi><sensei>", "3年B組金八先生", "3B-ww4c5e180e575a65lsy2b"},
+	{"(Q) <pafii>de<runba>", "パフィーdeルンバ", "de-jg4avhby1noc0d"},
+	{"(R) <sono><supiido><de>", "そのスピードで", "d9juau41awczczp"},
+	{"(S) -> $1.00 <-", "-> $1.00 <-", "-> $1.00 <--"},
+}
+
+func TestPunycode(t *testing.T) {
+	for _, tt := range punycodeTests {
+		encoded, err := punycodeEncode(tt.decoded)
+		if err != nil || encoded != tt.encoded {
+			t.Errorf("%s: punycodeEncode = %q, %v, want %q", tt.name, encoded, err, tt.encoded)
+		}
+
+		decoded, err := punycodeDecode(tt.encoded)
+		if err != nil || decoded != tt.decoded {
+			t.Errorf("%s: punycodeDecode = %q, %v, want %q", tt.name, decoded, err, tt.decoded)
+		}
+	}
+}
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		input   string
+		ascii   DomainName
+		unicode string
+	}{
+		{"example.com", "example.com", "example.com"},
+		{"bücher.example", "xn--bcher-kva.example", "bücher.example"},
+		{"Bücher.example.", "xn--bcher-kva.example.", "bücher.example."},
+		{"bücher。example", "xn--bcher-kva.example", "bücher.example"},
+		{"münchen.de", "xn--mnchen-3ya.de", "münchen.de"},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah", "例え.テスト"},
+		{"παράδειγμα.δοκιμή", "xn--hxajbheg2az3al.xn--jxalpdlp", "παράδειγμα.δοκιμή"},
+		{"пример.испытание", "xn--e1afmkfd.xn--80akhbyknj4f", "пример.испытание"},
+		{`a\.b.bücher`, `a\.b.xn--bcher-kva`, `a\.b.bücher`},
+	}
+	for _, tt := range tests {
+		ascii, err := ToASCII(tt.input)
+		if err != nil || ascii != tt.ascii {
+			t.Errorf("ToASCII(%q) = %q, %v, want %q", tt.input, ascii, err, tt.ascii)
+			continue
+		}
+
+		unicode, err := ascii.ToUnicode()
+		if err != nil || unicode != tt.unicode {
+			t.Errorf("DomainName(%q).ToUnicode() = %q, %v, want %q", ascii, unicode, err, tt.unicode)
+		}
+	}
+}
+
+func TestToASCIIRejectsInvalidLabels(t *testing.T) {
+	for _, input := range []string{
+		"-bücher.example",        // leading hyphen
+		"bücher-.example",        // trailing hyphen
+		"bü--cher.example",       // hyphens in the third and fourth positions
+		"\u0301bücher.example",   // leading combining mark
+		"a☃b.example",            // symbol
+		"xn--abc-.example",       // A-label that decodes to ASCII
+		"xn--bcher-kva9.example", // A-label that does not round-trip
+	} {
+		if got, err := ToASCII(input); err == nil {
+			t.Errorf("ToASCII(%q) = %q, want an error", input, got)
+		}
+	}
+}
+
+func TestStringUnicode(t *testing.T) {
+	tests := []struct {
+		name DomainName
+		want string
+	}{
+		{"xn--bcher-kva.example", "bücher.example."},
+		{"xn--r8jz45g.xn--zckzah.", "例え.テスト."},
+		{"xn--abc-.example", "xn--abc-.example."},
+		{"", "."},
+	}
+	for _, tt := range tests {
+		if got := tt.name.StringUnicode(); got != tt.want {
+			t.Errorf("DomainName(%q).StringUnicode() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	question := NewDNSQuestion("xn--bcher-kva.example", A)
+	if got, want := question.StringUnicode(), "bücher.example. IN A"; got != want {
+		t.Errorf("DNSQuestion.StringUnicode() = %q, want %q", got, want)
+	}
+	if got, want := question.String(), "xn--bcher-kva.example. IN A"; got != want {
+		t.Errorf("DNSQuestion.String() = %q, want %q", got, want)
+	}
+
+	record := MXRecord{Domain: "xn--mnchen-3ya.de", Class: ClassIN, Priority: 10, Host: "mail.xn--bcher-kva.example", TTL: 300}
+	if got, want := RecordStringUnicode(record), "münchen.de. 300 IN MX 10 mail.bücher.example."; got != want {
+		t.Errorf("RecordStringUnicode = %q, want %q", got, want)
+	}
+	if got, want := record.String(), "xn--mnchen-3ya.de. 300 IN MX 10 mail.xn--bcher-kva.example."; got != want {
+		t.Errorf("MXRecord.String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,691 @@
+package dns
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// The JSON layout follows RFC 8427: header fields sit at the top level of
+// the message and each section is a list of RR objects. Record data is
+// exposed as a typed "rdata" object, or as "RDATAHEX" for types we have no
+// codec for and for character-strings that are not valid UTF-8. Binary
+// fields are base64 encoded.
+
+type jsonHeader struct {
+	ID      uint16 `json:"ID"`
+	QR      bool   `json:"QR"`
+	Opcode  uint8  `json:"Opcode"`
+	AA      bool   `json:"AA"`
+	TC      bool   `json:"TC"`
+	RD      bool   `json:"RD"`
+	RA      bool   `json:"RA"`
+	Z       bool   `json:"Z"`
+	AD      bool   `json:"AD"`
+	CD      bool   `json:"CD"`
+	RCODE   uint8  `json:"RCODE"`
+	QDCOUNT uint16 `json:"QDCOUNT"`
+	ANCOUNT uint16 `json:"ANCOUNT"`
+	NSCOUNT uint16 `json:"NSCOUNT"`
+	ARCOUNT uint16 `json:"ARCOUNT"`
+}
+
+type jsonQuestion struct {
+	Name      DomainName `json:"NAME"`
+	Type      uint16     `json:"TYPE"`
+	TypeName  string     `json:"TYPEname"`
+	Class     uint16     `json:"CLASS"`
+	ClassName string     `json:"CLASSname"`
+}
+
+type jsonRecord struct {
+	Name      DomainName      `json:"NAME"`
+	Type      uint16          `json:"TYPE"`
+	TypeName  string          `json:"TYPEname"`
+	Class     uint16          `json:"CLASS"`
+	ClassName string          `json:"CLASSname,omitempty"`
+	TTL       uint32          `json:"TTL"`
+	RData     json.RawMessage `json:"rdata,omitempty"`
+	RDataHex  string          `json:"RDATAHEX,omitempty"`
+}
+
+type jsonPacket struct {
+	jsonHeader
+	Questions   []jsonQuestion    `json:"questionRRs"`
+	Answers     []json.RawMessage `json:"answerRRs"`
+	Authorities []json.RawMessage `json:"authorityRRs"`
+	Resources   []json.RawMessage `json:"additionalRRs"`
+}
+
+// jsonAddressRData carries the address as text so that the family is
+// explicit: an AAAA address is always written and read in IPv6 form.
+type jsonAddressRData struct {
+	Address string `json:"address"`
+}
+
+type jsonHostRData struct {
+	Host DomainName `json:"host"`
+}
+
+type jsonMXRData struct {
+	Preference uint16     `json:"preference"`
+	Exchange   DomainName `json:"exchange"`
+}
+
+type jsonSOARData struct {
+	MName   DomainName `json:"mname"`
+	RName   DomainName `json:"rname"`
+	Serial  uint32     `json:"serial"`
+	Refresh uint32     `json:"refresh"`
+	Retry   uint32     `json:"retry"`
+	Expire  uint32     `json:"expire"`
+	Minimum uint32     `json:"minimum"`
+}
+
+type jsonTXTRData struct {
+	Strings []string `json:"strings"`
+}
+
+type jsonSRVRData struct {
+	Priority uint16     `json:"priority"`
+	Weight   uint16     `json:"weight"`
+	Port     uint16     `json:"port"`
+	Target   DomainName `json:"target"`
+}
+
+type jsonNAPTRRData struct {
+	Order       uint16     `json:"order"`
+	Preference  uint16     `json:"preference"`
+	Flags       string     `json:"flags"`
+	Services    string     `json:"services"`
+	Regexp      string     `json:"regexp"`
+	Replacement DomainName `json:"replacement"`
+}
+
+type jsonSSHFPRData struct {
+	Algorithm   uint8  `json:"algorithm"`
+	FPType      uint8  `json:"fpType"`
+	Fingerprint []byte `json:"fingerprint"`
+}
+
+type jsonTLSARData struct {
+	Usage        uint8  `json:"usage"`
+	Selector     uint8  `json:"selector"`
+	MatchingType uint8  `json:"matchingType"`
+	Data         []byte `json:"data"`
+}
+
+type jsonCAARData struct {
+	Flags uint8  `json:"flags"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+type jsonDSRData struct {
+	KeyTag     uint16 `json:"keyTag"`
+	Algorithm  uint8  `json:"algorithm"`
+	DigestType uint8  `json:"digestType"`
+	Digest     []byte `json:"digest"`
+}
+
+type jsonDNSKEYRData struct {
+	Flags     uint16 `json:"flags"`
+	Protocol  uint8  `json:"protocol"`
+	Algorithm uint8  `json:"algorithm"`
+	PublicKey []byte `json:"publicKey"`
+}
+
+type jsonRRSIGRData struct {
+	TypeCovered string     `json:"typeCovered"`
+	Algorithm   uint8      `json:"algorithm"`
+	Labels      uint8      `json:"labels"`
+	OriginalTTL uint32     `json:"originalTTL"`
+	Expiration  uint32     `json:"expiration"`
+	Inception   uint32     `json:"inception"`
+	KeyTag      uint16     `json:"keyTag"`
+	SignerName  DomainName `json:"signerName"`
+	Signature   []byte     `json:"signature"`
+}
+
+type jsonNSECRData struct {
+	NextDomain DomainName `json:"nextDomain"`
+	Types      []string   `json:"types"`
+}
+
+type jsonNSEC3RData struct {
+	HashAlgorithm uint8    `json:"hashAlgorithm"`
+	Flags         uint8    `json:"flags"`
+	Iterations    uint16   `json:"iterations"`
+	Salt          []byte   `json:"salt"`
+	NextHashed    []byte   `json:"nextHashed,omitempty"`
+	Types         []string `json:"types,omitempty"`
+}
+
+type jsonSVCBRData struct {
+	Priority uint16     `json:"priority"`
+	Target   DomainName `json:"target"`
+	Params   []string   `json:"params"`
+}
+
+type jsonOPTOption struct {
+	Code uint16 `json:"code"`
+	Data []byte `json:"data"`
+}
+
+type jsonOPTRData struct {
+	UDPSize       uint16          `json:"udpSize"`
+	ExtendedRCode uint8           `json:"extendedRCode"`
+	Version       uint8           `json:"version"`
+	DO            bool            `json:"DO"`
+	Z             uint16          `json:"Z"`
+	Options       []jsonOPTOption `json:"options"`
+}
+
+func (h *DNSHeader) toJSON() jsonHeader {
+	return jsonHeader{
+		ID:      h.ID,
+		QR:      h.Response,
+		Opcode:  uint8(h.Opcode),
+		AA:      h.Authoritative,
+		TC:      h.TruncatedMessage,
+		RD:      h.RecursionDesired,
+		RA:      h.RecursionAvailable,
+		Z:       h.Z,
+		AD:      h.AuthedData,
+		CD:      h.CheckingDisabled,
+		RCODE:   uint8(h.ResCode),
+		QDCOUNT: h.QuestionCount,
+		ANCOUNT: h.AnswerCount,
+		NSCOUNT: h.AuthoritativeEntries,
+		ARCOUNT: h.ResourceEntries,
+	}
+}
+
+func (h *DNSHeader) fromJSON(j jsonHeader) {
+	h.ID = j.ID
+	h.Response = j.QR
+	h.Opcode = Opcode(j.Opcode)
+	h.Authoritative = j.AA
+	h.TruncatedMessage = j.TC
+	h.RecursionDesired = j.RD
+	h.RecursionAvailable = j.RA
+	h.Z = j.Z
+	h.AuthedData = j.AD
+	h.CheckingDisabled = j.CD
+	h.ResCode = ResultCode(j.RCODE)
+	h.QuestionCount = j.QDCOUNT
+	h.AnswerCount = j.ANCOUNT
+	h.AuthoritativeEntries = j.NSCOUNT
+	h.ResourceEntries = j.ARCOUNT
+}
+
+func (h *DNSHeader) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.toJSON())
+}
+
+func (h *DNSHeader) UnmarshalJSON(data []byte) error {
+	var j jsonHeader
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("DNSHeader.UnmarshalJSON: %s", err)
+	}
+	h.fromJSON(j)
+	return nil
+}
+
+func (q *DNSQuestion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonQuestion{q.Name, uint16(q.Type), q.Type.String(), uint16(q.Class), q.Class.String()})
+}
+
+func (q *DNSQuestion) UnmarshalJSON(data []byte) error {
+	var j jsonQuestion
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("DNSQuestion.UnmarshalJSON: %s", err)
+	}
+	q.Name = j.Name
+	q.Type = QueryType(j.Type)
+	q.Class = Class(j.Class)
+	return nil
+}
+
+func (d *DNSPacket) MarshalJSON() ([]byte, error) {
+	j := jsonPacket{jsonHeader: d.Header.toJSON()}
+	j.Questions = make([]jsonQuestion, 0, len(d.Questions))
+	for _, q := range d.Questions {
+		j.Questions = append(j.Questions, jsonQuestion{q.Name, uint16(q.Type), q.Type.String(), uint16(q.Class), q.Class.String()})
+	}
+
+	var err error
+	if j.Answers, err = marshalSection(d.Answers); err != nil {
+		return nil, fmt.Errorf("DNSPacket.MarshalJSON.Answers: %s", err)
+	}
+	if j.Authorities, err = marshalSection(d.Authorities); err != nil {
+		return nil, fmt.Errorf("DNSPacket.MarshalJSON.Authorities: %s", err)
+	}
+	if j.Resources, err = marshalSection(d.Resources); err != nil {
+		return nil, fmt.Errorf("DNSPacket.MarshalJSON.Resources: %s", err)
+	}
+
+	return json.Marshal(j)
+}
+
+func (d *DNSPacket) UnmarshalJSON(data []byte) error {
+	var j jsonPacket
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("DNSPacket.UnmarshalJSON: %s", err)
+	}
+
+	result := NewDNSPacket()
+	result.Header.fromJSON(j.jsonHeader)
+	for _, q := range j.Questions {
+		result.Questions = append(result.Questions, &DNSQuestion{q.Name, QueryType(q.Type), Class(q.Class)})
+	}
+
+	var err error
+	if result.Answers, err = unmarshalSection(j.Answers); err != nil {
+		return fmt.Errorf("DNSPacket.UnmarshalJSON.Answers: %s", err)
+	}
+	if result.Authorities, err = unmarshalSection(j.Authorities); err != nil {
+		return fmt.Errorf("DNSPacket.UnmarshalJSON.Authorities: %s", err)
+	}
+	if result.Resources, err = unmarshalSection(j.Resources); err != nil {
+		return fmt.Errorf("DNSPacket.UnmarshalJSON.Resources: %s", err)
+	}
+
+	*d = *result
+	return nil
+}
+
+func marshalSection(records []DnsRecord) ([]json.RawMessage, error) {
+	section := make([]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		data, err := MarshalRecordJSON(record)
+		if err != nil {
+			return nil, err
+		}
+		section = append(section, data)
+	}
+	return section, nil
+}
+
+func unmarshalSection(section []json.RawMessage) ([]DnsRecord, error) {
+	records := make([]DnsRecord, 0, len(section))
+	for _, data := range section {
+		record, err := UnmarshalRecordJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func typeNames(types []QueryType) []string {
+	names := make([]string, 0, len(types))
+	for _, qtype := range types {
+		names = append(names, qtype.String())
+	}
+	return names
+}
+
+func svcParamStrings(params []SvcParam) []string {
+	strs := make([]string, 0, len(params))
+	for _, param := range params {
+		strs = append(strs, param.String())
+	}
+	return strs
+}
+
+// MarshalRecordJSON encodes any DnsRecord as an RFC 8427 style RR object.
+func MarshalRecordJSON(record DnsRecord) ([]byte, error) {
+	var (
+		domain DomainName
+		qtype  QueryType
+		class  Class
+		ttl    uint32
+		rdata  interface{}
+		j      jsonRecord
+		// binary marks character-strings that a JSON string cannot carry
+		// byte for byte; such records fall back to RDATAHEX.
+		binary bool
+	)
+
+	switch record := record.(type) {
+	case ARecord:
+		domain, qtype, class, ttl = record.Domain, A, record.Class, record.TTL
+		rdata = jsonAddressRData{record.Addr.String()}
+	case AAAARecord:
+		domain, qtype, class, ttl = record.Domain, AAAA, record.Class, record.TTL
+		rdata = jsonAddressRData{formatIPv6(record.Addr)}
+	case NSRecord:
+		domain, qtype, class, ttl = record.Domain, NS, record.Class, record.TTL
+		rdata = jsonHostRData{record.Host}
+	case CNameRecord:
+		domain, qtype, class, ttl = record.Domain, CNAME, record.Class, record.TTL
+		rdata = jsonHostRData{record.Host}
+	case PTRRecord:
+		domain, qtype, class, ttl = record.Domain, PTR, record.Class, record.TTL
+		rdata = jsonHostRData{record.Host}
+	case DNAMERecord:
+		domain, qtype, class, ttl = record.Domain, DNAME, record.Class, record.TTL
+		rdata = jsonHostRData{record.Target}
+	case MXRecord:
+		domain, qtype, class, ttl = record.Domain, MX, record.Class, record.TTL
+		rdata = jsonMXRData{record.Priority, record.Host}
+	case SOARecord:
+		domain, qtype, class, ttl = record.Domain, SOA, record.Class, record.TTL
+		rdata = jsonSOARData{record.MName, record.RName, record.Serial, record.Refresh, record.Retry, record.Expire, record.Minimum}
+	case TXTRecord:
+		domain, qtype, class, ttl = record.Domain, TXT, record.Class, record.TTL
+		rdata = jsonTXTRData{record.Text}
+		binary = !validUTF8(record.Text...)
+	case SRVRecord:
+		domain, qtype, class, ttl = record.Domain, SRV, record.Class, record.TTL
+		rdata = jsonSRVRData{record.Priority, record.Weight, record.Port, record.Target}
+	case NAPTRRecord:
+		domain, qtype, class, ttl = record.Domain, NAPTR, record.Class, record.TTL
+		rdata = jsonNAPTRRData{record.Order, record.Preference, record.Flags, record.Services, record.Regexp, record.Replacement}
+		binary = !validUTF8(record.Flags, record.Services, record.Regexp)
+	case SSHFPRecord:
+		domain, qtype, class, ttl = record.Domain, SSHFP, record.Class, record.TTL
+		rdata = jsonSSHFPRData{record.Algorithm, record.FPType, record.Fingerprint}
+	case TLSARecord:
+		domain, qtype, class, ttl = record.Domain, TLSA, record.Class, record.TTL
+		rdata = jsonTLSARData{record.Usage, record.Selector, record.MatchingType, record.Data}
+	case CAARecord:
+		domain, qtype, class, ttl = record.Domain, CAA, record.Class, record.TTL
+		rdata = jsonCAARData{record.Flags, record.Tag, record.Value}
+		binary = !validUTF8(record.Tag, record.Value)
+	case DSRecord:
+		domain, qtype, class, ttl = record.Domain, DS, record.Class, record.TTL
+		rdata = jsonDSRData{record.KeyTag, record.Algorithm, record.DigestType, record.Digest}
+	case DNSKEYRecord:
+		domain, qtype, class, ttl = record.Domain, DNSKEY, record.Class, record.TTL
+		rdata = jsonDNSKEYRData{record.Flags, record.Protocol, record.Algorithm, record.PublicKey}
+	case RRSIGRecord:
+		domain, qtype, class, ttl = record.Domain, RRSIG, record.Class, record.TTL
+		rdata = jsonRRSIGRData{record.TypeCovered.String(), record.Algorithm, record.Labels, record.OriginalTTL, record.Expiration, record.Inception, record.KeyTag, record.SignerName, record.Signature}
+	case NSECRecord:
+		domain, qtype, class, ttl = record.Domain, NSEC, record.Class, record.TTL
+		rdata = jsonNSECRData{record.NextDomain, typeNames(record.Types)}
+	case NSEC3Record:
+		domain, qtype, class, ttl = record.Domain, NSEC3, record.Class, record.TTL
+		rdata = jsonNSEC3RData{record.HashAlgorithm, record.Flags, record.Iterations, record.Salt, record.NextHashed, typeNames(record.Types)}
+	case NSEC3PARAMRecord:
+		domain, qtype, class, ttl = record.Domain, NSEC3PARAM, record.Class, record.TTL
+		rdata = jsonNSEC3RData{HashAlgorithm: record.HashAlgorithm, Flags: record.Flags, Iterations: record.Iterations, Salt: record.Salt}
+	case SVCBRecord:
+		domain, qtype, class, ttl = record.Domain, SVCB, record.Class, record.TTL
+		rdata = jsonSVCBRData{record.Priority, record.Target, svcParamStrings(record.Params)}
+	case HTTPSRecord:
+		domain, qtype, class, ttl = record.Domain, HTTPS, record.Class, record.TTL
+		rdata = jsonSVCBRData{record.Priority, record.Target, svcParamStrings(record.Params)}
+	case OPTRecord:
+		domain, qtype, class, ttl = "", OPT, Class(record.UDPSize), record.ttl()
+		options := make([]jsonOPTOption, 0, len(record.Options))
+		for _, option := range record.Options {
+			options = append(options, jsonOPTOption{uint16(option.Code), option.Data})
+		}
+		rdata = jsonOPTRData{record.UDPSize, record.ExtendedRCode, record.Version, record.DO, record.Z, options}
+	case UnknownRecord:
+		domain, qtype, class, ttl = record.Domain, QueryType(record.Type), record.Class, record.TTL
+		j.RDataHex = fmt.Sprintf("%X", record.Data)
+	default:
+		return nil, fmt.Errorf("MarshalRecordJSON: unsupported record %T", record)
+	}
+
+	j.Name, j.Type, j.TypeName, j.Class, j.TTL = domain, uint16(qtype), qtype.String(), uint16(class), ttl
+	if qtype != OPT {
+		j.ClassName = class.String()
+	}
+
+	if binary {
+		raw, err := rawRData(record)
+		if err != nil {
+			return nil, fmt.Errorf("MarshalRecordJSON.RDATAHEX: %s", err)
+		}
+		rdata, j.RDataHex = nil, fmt.Sprintf("%X", raw)
+	}
+
+	if rdata != nil {
+		data, err := json.Marshal(rdata)
+		if err != nil {
+			return nil, fmt.Errorf("MarshalRecordJSON.rdata: %s", err)
+		}
+		j.RData = data
+	}
+
+	return json.Marshal(j)
+}
+
+func validUTF8(strs ...string) bool {
+	for _, s := range strs {
+		if !utf8.ValidString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// rawRData returns the wire-format RDATA of record on its own.
+func rawRData(record DnsRecord) ([]byte, error) {
+	buffer := NewBytePacketBufferSize(MaxTCPPacketSize)
+	if _, err := WriteDNSRecord(buffer, record); err != nil {
+		return nil, err
+	}
+
+	buffer.Seek(0)
+	var owner DomainName
+	if err := buffer.ReadQName(&owner); err != nil {
+		return nil, err
+	}
+	buffer.Step(8)
+	dataLength, err := buffer.ReadU16()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.ReadBytes(uint(dataLength))
+}
+
+// UnmarshalRecordJSON decodes an RR object produced by MarshalRecordJSON
+// back into the matching typed record.
+func UnmarshalRecordJSON(data []byte) (DnsRecord, error) {
+	var j jsonRecord
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("UnmarshalRecordJSON: %s", err)
+	}
+
+	domain, qtype, class, ttl := j.Name, QueryType(j.Type), Class(j.Class), j.TTL
+
+	if j.RData == nil {
+		if qtype == OPT {
+			return nil, fmt.Errorf("UnmarshalRecordJSON: OPT needs typed rdata")
+		}
+		raw, err := hex.DecodeString(j.RDataHex)
+		if err != nil {
+			return nil, fmt.Errorf("UnmarshalRecordJSON.RDATAHEX: %s", err)
+		}
+		record, err := decodeRData(domain, ttl, class, qtype, raw)
+		if err != nil {
+			return nil, fmt.Errorf("UnmarshalRecordJSON.RDATAHEX: %s", err)
+		}
+		return record, nil
+	}
+
+	decode := func(v interface{}) error {
+		if err := json.Unmarshal(j.RData, v); err != nil {
+			return fmt.Errorf("UnmarshalRecordJSON.rdata: %s", err)
+		}
+		return nil
+	}
+
+	switch qtype {
+	case A, AAAA:
+		var r jsonAddressRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		if qtype == A {
+			addr := parseIPv4(r.Address)
+			if addr == nil {
+				return nil, fmt.Errorf("UnmarshalRecordJSON: A address %q is not IPv4", r.Address)
+			}
+			return ARecord{domain, class, addr, ttl}, nil
+		}
+		addr := parseIPv6(r.Address)
+		if addr == nil {
+			return nil, fmt.Errorf("UnmarshalRecordJSON: AAAA address %q is not IPv6", r.Address)
+		}
+		return AAAARecord{domain, class, addr, ttl}, nil
+	case NS, CNAME, PTR, DNAME:
+		var r jsonHostRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		switch qtype {
+		case NS:
+			return NSRecord{domain, class, r.Host, ttl}, nil
+		case CNAME:
+			return CNameRecord{domain, class, r.Host, ttl}, nil
+		case PTR:
+			return PTRRecord{domain, class, r.Host, ttl}, nil
+		default:
+			return DNAMERecord{domain, class, r.Host, ttl}, nil
+		}
+	case MX:
+		var r jsonMXRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return MXRecord{domain, class, r.Preference, r.Exchange, ttl}, nil
+	case SOA:
+		var r jsonSOARData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return SOARecord{domain, class, r.MName, r.RName, r.Serial, r.Refresh, r.Retry, r.Expire, r.Minimum, ttl}, nil
+	case TXT:
+		var r jsonTXTRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return TXTRecord{domain, class, r.Strings, ttl}, nil
+	case SRV:
+		var r jsonSRVRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return SRVRecord{domain, class, r.Priority, r.Weight, r.Port, r.Target, ttl}, nil
+	case NAPTR:
+		var r jsonNAPTRRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return NAPTRRecord{domain, class, r.Order, r.Preference, r.Flags, r.Services, r.Regexp, r.Replacement, ttl}, nil
+	case SSHFP:
+		var r jsonSSHFPRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return SSHFPRecord{domain, class, r.Algorithm, r.FPType, r.Fingerprint, ttl}, nil
+	case TLSA:
+		var r jsonTLSARData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return TLSARecord{domain, class, r.Usage, r.Selector, r.MatchingType, r.Data, ttl}, nil
+	case CAA:
+		var r jsonCAARData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return CAARecord{domain, class, r.Flags, r.Tag, r.Value, ttl}, nil
+	case DS:
+		var r jsonDSRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return DSRecord{domain, class, r.KeyTag, r.Algorithm, r.DigestType, r.Digest, ttl}, nil
+	case DNSKEY:
+		var r jsonDNSKEYRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		return DNSKEYRecord{domain, class, r.Flags, r.Protocol, r.Algorithm, r.PublicKey, ttl}, nil
+	case RRSIG:
+		var r jsonRRSIGRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		typeCovered, err := ParseQueryType(r.TypeCovered)
+		if err != nil {
+			return nil, fmt.Errorf("UnmarshalRecordJSON.typeCovered: %s", err)
+		}
+		return RRSIGRecord{domain, class, typeCovered, r.Algorithm, r.Labels, r.OriginalTTL, r.Expiration, r.Inception, r.KeyTag, r.SignerName, r.Signature, ttl}, nil
+	case NSEC:
+		var r jsonNSECRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		types, err := parseTypeNames(r.Types)
+		if err != nil {
+			return nil, err
+		}
+		return NSECRecord{domain, class, r.NextDomain, types, ttl}, nil
+	case NSEC3, NSEC3PARAM:
+		var r jsonNSEC3RData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		if qtype == NSEC3PARAM {
+			return NSEC3PARAMRecord{domain, class, r.HashAlgorithm, r.Flags, r.Iterations, r.Salt, ttl}, nil
+		}
+		types, err := parseTypeNames(r.Types)
+		if err != nil {
+			return nil, err
+		}
+		return NSEC3Record{domain, class, r.HashAlgorithm, r.Flags, r.Iterations, r.Salt, r.NextHashed, types, ttl}, nil
+	case SVCB, HTTPS:
+		var r jsonSVCBRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		params := make([]SvcParam, 0, len(r.Params))
+		for _, s := range r.Params {
+			param, err := parseSvcParam(s)
+			if err != nil {
+				return nil, fmt.Errorf("UnmarshalRecordJSON.params: %s", err)
+			}
+			params = append(params, param)
+		}
+		record := SVCBRecord{domain, class, r.Priority, r.Target, params, ttl}
+		if qtype == HTTPS {
+			return HTTPSRecord(record), nil
+		}
+		return record, nil
+	case OPT:
+		var r jsonOPTRData
+		if err := decode(&r); err != nil {
+			return nil, err
+		}
+		options := make([]EDNSOption, 0, len(r.Options))
+		for _, option := range r.Options {
+			options = append(options, EDNSOption{EDNSOptionCode(option.Code), option.Data})
+		}
+		return OPTRecord{r.UDPSize, r.ExtendedRCode, r.Version, r.DO, r.Z, options}, nil
+	default:
+		return nil, fmt.Errorf("UnmarshalRecordJSON: no typed rdata for %s", qtype)
+	}
+}
+
+func parseTypeNames(names []string) ([]QueryType, error) {
+	types := make([]QueryType, 0, len(names))
+	for _, name := range names {
+		qtype, err := ParseQueryType(name)
+		if err != nil {
+			return nil, fmt.Errorf("UnmarshalRecordJSON.types: %s", err)
+		}
+		types = append(types, qtype)
+	}
+	return types, nil
+}
@@ -0,0 +1,95 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+// wireBytes encodes packet into a fresh buffer.
+func wireBytes(t *testing.T, packet *DNSPacket) []byte {
+	t.Helper()
+
+	buffer := NewBytePacketBufferSize(MaxTCPPacketSize)
+	if err := packet.Write(buffer); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	data, err := buffer.GetRange(0, buffer.Pos())
+	if err != nil {
+		t.Fatalf("GetRange: %v", err)
+	}
+	return data
+}
+
+func TestJSONWireRoundTrip(t *testing.T) {
+	packet := NewDNSPacket()
+	packet.Header.ID = 0xBEEF
+	packet.Header.Response = true
+	packet.Header.RecursionDesired = true
+	packet.Questions = append(packet.Questions, NewDNSQuestion("example.com", A))
+	packet.Answers = testRecords()
+	packet.Resources = append(packet.Resources, NewOPTRecord(1232, true))
+
+	want := wireBytes(t, packet)
+
+	decoded, err := NewDNSPacket().Read(NewBytePacketBufferFrom(want))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+
+	fromJSON := NewDNSPacket()
+	if err := json.Unmarshal(encoded, fromJSON); err != nil {
+		t.Fatalf("UnmarshalJSON(%s): %v", encoded, err)
+	}
+
+	if got := wireBytes(t, fromJSON); !bytes.Equal(got, want) {
+		t.Errorf("wire -> JSON -> wire changed the message\njson: %s\ngot:  %x\nwant: %x", encoded, got, want)
+	}
+}
+
+func TestUnmarshalRecordJSONRejectsWrongFamily(t *testing.T) {
+	for _, data := range []string{
+		`{"NAME":"example.com","TYPE":1,"CLASS":1,"TTL":300,"rdata":{"address":"::1"}}`,
+		`{"NAME":"example.com","TYPE":1,"CLASS":1,"TTL":300,"rdata":{}}`,
+		`{"NAME":"example.com","TYPE":28,"CLASS":1,"TTL":300,"rdata":{}}`,
+		`{"NAME":"example.com","TYPE":28,"CLASS":1,"TTL":300,"rdata":{"address":"192.0.2.1"}}`,
+	} {
+		if record, err := UnmarshalRecordJSON([]byte(data)); err == nil {
+			t.Errorf("UnmarshalRecordJSON(%s) = %v, want an error", data, record)
+		}
+	}
+}
+
+func TestJSONKeepsIPv4MappedAAAA(t *testing.T) {
+	record := AAAARecord{Domain: "example.com", Class: ClassIN, Addr: net.ParseIP("::ffff:192.0.2.1"), TTL: 300}
+
+	encoded, err := MarshalRecordJSON(record)
+	if err != nil {
+		t.Fatalf("MarshalRecordJSON: %v", err)
+	}
+	decoded, err := UnmarshalRecordJSON(encoded)
+	if err != nil {
+		t.Fatalf("UnmarshalRecordJSON(%s): %v", encoded, err)
+	}
+	if got, ok := decoded.(AAAARecord); !ok || !got.Addr.Equal(record.Addr) {
+		t.Errorf("UnmarshalRecordJSON(%s) = %v, want %v", encoded, decoded, record)
+	}
+}
+
+func TestWriteDNSRecordRejectsWrongFamily(t *testing.T) {
+	for _, record := range []DnsRecord{
+		ARecord{Domain: "example.com", Class: ClassIN, Addr: net.ParseIP("::1"), TTL: 300},
+		ARecord{Domain: "example.com", Class: ClassIN, TTL: 300},
+		AAAARecord{Domain: "example.com", Class: ClassIN, TTL: 300},
+		AAAARecord{Domain: "example.com", Class: ClassIN, Addr: net.IP{192, 0, 2, 1}, TTL: 300},
+	} {
+		if _, err := WriteDNSRecord(NewBytePacketBuffer(), record); err == nil {
+			t.Errorf("WriteDNSRecord(%v): got no error", record)
+		}
+	}
+}
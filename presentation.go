@@ -0,0 +1,926 @@
+package dns
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fqdn renders a name the way zone files and dig do, with a trailing dot.
+func fqdn(name DomainName) string {
+	if name == "" || name == "." {
+		return "."
+	}
+	if name.IsFQDN() {
+		return string(name)
+	}
+	return string(name) + "."
+}
+
+// formatIPv6 renders an IPv6 address. net.IP.String prints IPv4-mapped
+// addresses as dotted quads, which would read back as IPv4, so those keep
+// their "::ffff:" prefix.
+func formatIPv6(ip net.IP) string {
+	if len(ip) == net.IPv6len && ip.To4() != nil {
+		return "::ffff:" + ip.To4().String()
+	}
+	return ip.String()
+}
+
+// parseIPv4 parses an IPv4 address in dotted-quad form.
+func parseIPv4(s string) net.IP {
+	if strings.Contains(s, ":") {
+		return nil
+	}
+	return net.ParseIP(s).To4()
+}
+
+// parseIPv6 parses an IPv6 address in presentation format. Dotted quads are
+// IPv4 and rejected; IPv4-mapped addresses need the "::ffff:" prefix.
+func parseIPv6(s string) net.IP {
+	if !strings.Contains(s, ":") {
+		return nil
+	}
+	return net.ParseIP(s)
+}
+
+// formatRecord renders a record in RFC 1035 presentation format:
+// "name TTL class type rdata".
+func formatRecord(domain DomainName, ttl uint32, class Class, qtype QueryType, rdata string) string {
+	if rdata == "" {
+		return fmt.Sprintf("%s %d %s %s", fqdn(domain), ttl, class, qtype)
+	}
+	return fmt.Sprintf("%s %d %s %s %s", fqdn(domain), ttl, class, qtype, rdata)
+}
+
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// zoneEntry is one logical line of a zone file: parentheses have been
+// folded and comments removed.
+type zoneEntry struct {
+	tokens     []zoneToken
+	blankOwner bool
+	line       int
+}
+
+// splitZone breaks zone-file text into logical entries. Escapes are kept
+// verbatim in the token text; quotes are stripped and recorded in quoted.
+func splitZone(text string) ([]zoneEntry, error) {
+	entries := make([]zoneEntry, 0)
+	current := zoneEntry{line: 1}
+	var token strings.Builder
+	inToken, inQuote, quoted := false, false, false
+	depth, line := 0, 1
+	atLineStart := true
+
+	flushToken := func() {
+		if inToken {
+			current.tokens = append(current.tokens, zoneToken{text: token.String(), quoted: quoted})
+		}
+		token.Reset()
+		inToken, quoted = false, false
+	}
+	flushEntry := func() {
+		if len(current.tokens) > 0 {
+			entries = append(entries, current)
+		}
+		current = zoneEntry{line: line + 1}
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+
+		if atLineStart && depth == 0 {
+			current.blankOwner = c == ' ' || c == '\t'
+			current.line = line
+		}
+		atLineStart = false
+
+		switch {
+		case c == '\\':
+			inToken = true
+			token.WriteByte(c)
+			if i+1 < len(text) {
+				i++
+				token.WriteByte(text[i])
+			}
+		case inQuote:
+			if c == '"' {
+				inQuote = false
+			} else {
+				if c == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated quoted string", line)
+				}
+				token.WriteByte(c)
+			}
+		case c == '"':
+			inToken, inQuote, quoted = true, true, true
+		case c == ';':
+			for i+1 < len(text) && text[i+1] != '\n' {
+				i++
+			}
+		case c == '(':
+			flushToken()
+			depth++
+		case c == ')':
+			flushToken()
+			if depth == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced ')'", line)
+			}
+			depth--
+		case c == '\n':
+			flushToken()
+			if depth == 0 {
+				flushEntry()
+			}
+			line++
+			atLineStart = true
+		case c == ' ' || c == '\t' || c == '\r':
+			flushToken()
+		default:
+			inToken = true
+			token.WriteByte(c)
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("line %d: unterminated quoted string", line)
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("line %d: unbalanced '('", line)
+	}
+	flushToken()
+	flushEntry()
+
+	return entries, nil
+}
+
+// unescapeCharacterString resolves \X and \DDD escapes.
+func unescapeCharacterString(s string) (string, error) {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			builder.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", fmt.Errorf("dangling escape in %q", s)
+		}
+		if i+3 < len(s) && isDigit(s[i+1]) && isDigit(s[i+2]) && isDigit(s[i+3]) {
+			n, _ := strconv.Atoi(s[i+1 : i+4])
+			if n > 0xFF {
+				return "", fmt.Errorf("escape \\%s out of range", s[i+1:i+4])
+			}
+			builder.WriteByte(byte(n))
+			i += 3
+			continue
+		}
+		builder.WriteByte(s[i+1])
+		i++
+	}
+	return builder.String(), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseName turns a presentation name into the dotless form the codec
+// uses, resolving "@" and relative names against origin.
+func parseName(s string, origin DomainName) DomainName {
+	if origin.IsFQDN() {
+		origin = origin[:len(origin)-1]
+	}
+	name := DomainName(s)
+	switch {
+	case s == "@":
+		return origin
+	case s == ".":
+		return ""
+	case name.IsFQDN():
+		return name[:len(name)-1]
+	case origin == "":
+		return name
+	default:
+		return name + "." + origin
+	}
+}
+
+// parseTTL accepts plain seconds or BIND-style unit suffixes such as "1h30m".
+func parseTTL(s string) (uint32, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(n), nil
+	}
+
+	var total, value uint64
+	digits := false
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			value = value*10 + uint64(c-'0')
+			digits = true
+			continue
+		}
+
+		var unit uint64
+		switch c {
+		case 's':
+			unit = 1
+		case 'm':
+			unit = 60
+		case 'h':
+			unit = 3600
+		case 'd':
+			unit = 86400
+		case 'w':
+			unit = 604800
+		default:
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		if !digits {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		total += value * unit
+		value, digits = 0, false
+	}
+
+	if s == "" || digits || total > 0xFFFFFFFF {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	return uint32(total), nil
+}
+
+func parseUint(s string, bits int) (uint64, error) {
+	n, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %d-bit integer %q", bits, s)
+	}
+	return n, nil
+}
+
+// parseSignatureTime accepts YYYYMMDDHHmmSS or seconds since the epoch.
+func parseSignatureTime(s string) (uint32, error) {
+	if len(s) == 14 {
+		t, err := time.Parse("20060102150405", s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid signature time %q", s)
+		}
+		return uint32(t.Unix()), nil
+	}
+
+	n, err := parseUint(s, 32)
+	return uint32(n), err
+}
+
+func joinTokens(tokens []zoneToken) string {
+	var builder strings.Builder
+	for _, token := range tokens {
+		builder.WriteString(token.text)
+	}
+	return builder.String()
+}
+
+// ParseRecord parses a single presentation line such as
+// "www.example.com. 300 IN A 192.0.2.1". Relative names are completed
+// with origin.
+func ParseRecord(line string, origin DomainName) (DnsRecord, error) {
+	entries, err := splitZone(line)
+	if err != nil {
+		return nil, fmt.Errorf("ParseRecord: %s", err)
+	}
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("ParseRecord: expected one record, found %d", len(entries))
+	}
+	if entries[0].blankOwner {
+		return nil, fmt.Errorf("ParseRecord: missing owner name")
+	}
+
+	tokens := entries[0].tokens
+	record, _, _, err := parseRecordTokens(parseName(tokens[0].text, origin), tokens[1:], origin, 0, ClassIN, false)
+	if err != nil {
+		return nil, fmt.Errorf("ParseRecord: %s", err)
+	}
+	return record, nil
+}
+
+// ParseZone reads a master file as described in RFC 1035 section 5. It
+// understands $ORIGIN and $TTL, parentheses, comments and blank owners.
+func ParseZone(r io.Reader, origin DomainName) ([]DnsRecord, error) {
+	var text strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text.WriteString(scanner.Text())
+		text.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ParseZone: %s", err)
+	}
+
+	entries, err := splitZone(text.String())
+	if err != nil {
+		return nil, fmt.Errorf("ParseZone: %s", err)
+	}
+
+	records := make([]DnsRecord, 0)
+	var owner DomainName
+	class := ClassIN
+	haveOwner := false
+	// zoneTTL comes from $TTL; without it the last explicit TTL carries
+	// forward (RFC 2308 section 4).
+	var zoneTTL, lastTTL uint32
+	haveZoneTTL, haveLastTTL := false, false
+
+	for _, entry := range entries {
+		tokens := entry.tokens
+
+		if !entry.blankOwner && strings.HasPrefix(tokens[0].text, "$") {
+			switch strings.ToUpper(tokens[0].text) {
+			case "$ORIGIN":
+				if len(tokens) != 2 {
+					return nil, fmt.Errorf("ParseZone: line %d: $ORIGIN takes one name", entry.line)
+				}
+				origin = parseName(tokens[1].text, origin)
+			case "$TTL":
+				if len(tokens) != 2 {
+					return nil, fmt.Errorf("ParseZone: line %d: $TTL takes one value", entry.line)
+				}
+				ttl, err := parseTTL(tokens[1].text)
+				if err != nil {
+					return nil, fmt.Errorf("ParseZone: line %d: %s", entry.line, err)
+				}
+				zoneTTL, haveZoneTTL = ttl, true
+			default:
+				return nil, fmt.Errorf("ParseZone: line %d: unsupported directive %s", entry.line, tokens[0].text)
+			}
+			continue
+		}
+
+		if entry.blankOwner {
+			if !haveOwner {
+				return nil, fmt.Errorf("ParseZone: line %d: no previous owner name", entry.line)
+			}
+		} else {
+			owner, haveOwner = parseName(tokens[0].text, origin), true
+			tokens = tokens[1:]
+		}
+
+		defaultTTL, haveTTL := lastTTL, haveLastTTL
+		if haveZoneTTL {
+			defaultTTL, haveTTL = zoneTTL, true
+		}
+
+		record, ttl, recordClass, err := parseRecordTokens(owner, tokens, origin, defaultTTL, class, haveTTL)
+		if err != nil {
+			return nil, fmt.Errorf("ParseZone: line %d: %s", entry.line, err)
+		}
+		lastTTL, haveLastTTL, class = ttl, true, recordClass
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// parseRecordTokens parses "[TTL] [class] type rdata" in either order of
+// TTL and class. It returns the TTL and class it settled on so zone
+// parsing can carry them forward.
+func parseRecordTokens(owner DomainName, tokens []zoneToken, origin DomainName, ttl uint32, class Class, haveTTL bool) (DnsRecord, uint32, Class, error) {
+	sawTTL, sawClass := false, false
+	for len(tokens) > 0 {
+		if !sawTTL && tokens[0].text != "" && isDigit(tokens[0].text[0]) {
+			value, err := parseTTL(tokens[0].text)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			ttl, sawTTL = value, true
+			tokens = tokens[1:]
+			continue
+		}
+		if !sawClass {
+			if value, err := ParseClass(tokens[0].text); err == nil {
+				class, sawClass = value, true
+				tokens = tokens[1:]
+				continue
+			}
+		}
+		break
+	}
+
+	if !sawTTL && !haveTTL {
+		return nil, 0, 0, fmt.Errorf("no TTL for %s", fqdn(owner))
+	}
+	if len(tokens) == 0 {
+		return nil, 0, 0, fmt.Errorf("missing type for %s", fqdn(owner))
+	}
+
+	qtype, err := ParseQueryType(tokens[0].text)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	record, err := parseRData(owner, ttl, class, qtype, tokens[1:], origin)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("%s %s: %s", fqdn(owner), qtype, err)
+	}
+	return record, ttl, class, nil
+}
+
+func expectTokens(tokens []zoneToken, n int) error {
+	if len(tokens) != n {
+		return fmt.Errorf("expected %d RDATA fields, found %d", n, len(tokens))
+	}
+	return nil
+}
+
+func parseRData(owner DomainName, ttl uint32, class Class, qtype QueryType, tokens []zoneToken, origin DomainName) (DnsRecord, error) {
+	if len(tokens) > 0 && tokens[0].text == "\\#" && !tokens[0].quoted {
+		return parseGenericRData(owner, ttl, class, qtype, tokens[1:])
+	}
+
+	switch qtype {
+	case A:
+		if err := expectTokens(tokens, 1); err != nil {
+			return nil, err
+		}
+		addr := parseIPv4(tokens[0].text)
+		if addr == nil {
+			return nil, fmt.Errorf("invalid IPv4 address %q", tokens[0].text)
+		}
+		return ARecord{owner, class, addr, ttl}, nil
+
+	case AAAA:
+		if err := expectTokens(tokens, 1); err != nil {
+			return nil, err
+		}
+		addr := parseIPv6(tokens[0].text)
+		if addr == nil {
+			return nil, fmt.Errorf("invalid IPv6 address %q", tokens[0].text)
+		}
+		return AAAARecord{owner, class, addr, ttl}, nil
+
+	case NS, CNAME, PTR, DNAME:
+		if err := expectTokens(tokens, 1); err != nil {
+			return nil, err
+		}
+		host := parseName(tokens[0].text, origin)
+		switch qtype {
+		case NS:
+			return NSRecord{owner, class, host, ttl}, nil
+		case CNAME:
+			return CNameRecord{owner, class, host, ttl}, nil
+		case PTR:
+			return PTRRecord{owner, class, host, ttl}, nil
+		default:
+			return DNAMERecord{owner, class, host, ttl}, nil
+		}
+
+	case MX:
+		if err := expectTokens(tokens, 2); err != nil {
+			return nil, err
+		}
+		prio, err := parseUint(tokens[0].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		return MXRecord{owner, class, uint16(prio), parseName(tokens[1].text, origin), ttl}, nil
+
+	case SOA:
+		if err := expectTokens(tokens, 7); err != nil {
+			return nil, err
+		}
+		var timers [5]uint32
+		for i := range timers {
+			value, err := parseTTL(tokens[2+i].text)
+			if err != nil {
+				return nil, err
+			}
+			timers[i] = value
+		}
+		return SOARecord{owner, class, parseName(tokens[0].text, origin), parseName(tokens[1].text, origin), timers[0], timers[1], timers[2], timers[3], timers[4], ttl}, nil
+
+	case TXT:
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("expected at least one character-string")
+		}
+		txt := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			s, err := unescapeCharacterString(token.text)
+			if err != nil {
+				return nil, err
+			}
+			txt = append(txt, s)
+		}
+		return TXTRecord{owner, class, txt, ttl}, nil
+
+	case SRV:
+		if err := expectTokens(tokens, 4); err != nil {
+			return nil, err
+		}
+		var fields [3]uint16
+		for i := range fields {
+			value, err := parseUint(tokens[i].text, 16)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = uint16(value)
+		}
+		return SRVRecord{owner, class, fields[0], fields[1], fields[2], parseName(tokens[3].text, origin), ttl}, nil
+
+	case NAPTR:
+		if err := expectTokens(tokens, 6); err != nil {
+			return nil, err
+		}
+		order, err := parseUint(tokens[0].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		preference, err := parseUint(tokens[1].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		var strs [3]string
+		for i := range strs {
+			if strs[i], err = unescapeCharacterString(tokens[2+i].text); err != nil {
+				return nil, err
+			}
+		}
+		return NAPTRRecord{owner, class, uint16(order), uint16(preference), strs[0], strs[1], strs[2], parseName(tokens[5].text, origin), ttl}, nil
+
+	case SSHFP:
+		if len(tokens) < 3 {
+			return nil, fmt.Errorf("expected algorithm, type and fingerprint")
+		}
+		algorithm, err := parseUint(tokens[0].text, 8)
+		if err != nil {
+			return nil, err
+		}
+		fpType, err := parseUint(tokens[1].text, 8)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint, err := hex.DecodeString(joinTokens(tokens[2:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid fingerprint: %s", err)
+		}
+		return SSHFPRecord{owner, class, uint8(algorithm), uint8(fpType), fingerprint, ttl}, nil
+
+	case TLSA:
+		if len(tokens) < 4 {
+			return nil, fmt.Errorf("expected usage, selector, matching type and data")
+		}
+		var fields [3]uint8
+		for i := range fields {
+			value, err := parseUint(tokens[i].text, 8)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = uint8(value)
+		}
+		data, err := hex.DecodeString(joinTokens(tokens[3:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate data: %s", err)
+		}
+		return TLSARecord{owner, class, fields[0], fields[1], fields[2], data, ttl}, nil
+
+	case CAA:
+		if err := expectTokens(tokens, 3); err != nil {
+			return nil, err
+		}
+		flags, err := parseUint(tokens[0].text, 8)
+		if err != nil {
+			return nil, err
+		}
+		value, err := unescapeCharacterString(tokens[2].text)
+		if err != nil {
+			return nil, err
+		}
+		return CAARecord{owner, class, uint8(flags), tokens[1].text, value, ttl}, nil
+
+	case DS:
+		if len(tokens) < 4 {
+			return nil, fmt.Errorf("expected key tag, algorithm, digest type and digest")
+		}
+		keyTag, err := parseUint(tokens[0].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		algorithm, err := parseUint(tokens[1].text, 8)
+		if err != nil {
+			return nil, err
+		}
+		digestType, err := parseUint(tokens[2].text, 8)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := hex.DecodeString(joinTokens(tokens[3:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest: %s", err)
+		}
+		return DSRecord{owner, class, uint16(keyTag), uint8(algorithm), uint8(digestType), digest, ttl}, nil
+
+	case DNSKEY:
+		if len(tokens) < 4 {
+			return nil, fmt.Errorf("expected flags, protocol, algorithm and public key")
+		}
+		flags, err := parseUint(tokens[0].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		protocol, err := parseUint(tokens[1].text, 8)
+		if err != nil {
+			return nil, err
+		}
+		algorithm, err := parseUint(tokens[2].text, 8)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := base64.StdEncoding.DecodeString(joinTokens(tokens[3:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %s", err)
+		}
+		return DNSKEYRecord{owner, class, uint16(flags), uint8(protocol), uint8(algorithm), publicKey, ttl}, nil
+
+	case RRSIG:
+		if len(tokens) < 9 {
+			return nil, fmt.Errorf("expected 9 RDATA fields, found %d", len(tokens))
+		}
+		typeCovered, err := ParseQueryType(tokens[0].text)
+		if err != nil {
+			return nil, err
+		}
+		var small [2]uint8
+		for i := range small {
+			value, err := parseUint(tokens[1+i].text, 8)
+			if err != nil {
+				return nil, err
+			}
+			small[i] = uint8(value)
+		}
+		originalTTL, err := parseTTL(tokens[3].text)
+		if err != nil {
+			return nil, err
+		}
+		expiration, err := parseSignatureTime(tokens[4].text)
+		if err != nil {
+			return nil, err
+		}
+		inception, err := parseSignatureTime(tokens[5].text)
+		if err != nil {
+			return nil, err
+		}
+		keyTag, err := parseUint(tokens[6].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := base64.StdEncoding.DecodeString(joinTokens(tokens[8:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature: %s", err)
+		}
+		return RRSIGRecord{owner, class, typeCovered, small[0], small[1], originalTTL, expiration, inception, uint16(keyTag), parseName(tokens[7].text, origin), signature, ttl}, nil
+
+	case NSEC:
+		if len(tokens) < 1 {
+			return nil, fmt.Errorf("expected next domain name")
+		}
+		types, err := parseTypeList(tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		return NSECRecord{owner, class, parseName(tokens[0].text, origin), types, ttl}, nil
+
+	case NSEC3, NSEC3PARAM:
+		if len(tokens) < 4 {
+			return nil, fmt.Errorf("expected hash algorithm, flags, iterations and salt")
+		}
+		var small [2]uint8
+		for i := range small {
+			value, err := parseUint(tokens[i].text, 8)
+			if err != nil {
+				return nil, err
+			}
+			small[i] = uint8(value)
+		}
+		iterations, err := parseUint(tokens[2].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		salt := []byte{}
+		if tokens[3].text != "-" {
+			if salt, err = hex.DecodeString(tokens[3].text); err != nil {
+				return nil, fmt.Errorf("invalid salt: %s", err)
+			}
+		}
+
+		if qtype == NSEC3PARAM {
+			if err := expectTokens(tokens, 4); err != nil {
+				return nil, err
+			}
+			return NSEC3PARAMRecord{owner, class, small[0], small[1], uint16(iterations), salt, ttl}, nil
+		}
+
+		if len(tokens) < 5 {
+			return nil, fmt.Errorf("expected next hashed owner name")
+		}
+		nextHashed, err := nsec3Encoding.DecodeString(strings.ToUpper(tokens[4].text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid next hashed owner name: %s", err)
+		}
+		types, err := parseTypeList(tokens[5:])
+		if err != nil {
+			return nil, err
+		}
+		return NSEC3Record{owner, class, small[0], small[1], uint16(iterations), salt, nextHashed, types, ttl}, nil
+
+	case SVCB, HTTPS:
+		if len(tokens) < 2 {
+			return nil, fmt.Errorf("expected priority and target")
+		}
+		priority, err := parseUint(tokens[0].text, 16)
+		if err != nil {
+			return nil, err
+		}
+		params := make([]SvcParam, 0, len(tokens)-2)
+		for _, token := range tokens[2:] {
+			param, err := parseSvcParam(token.text)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param)
+		}
+
+		record := SVCBRecord{owner, class, uint16(priority), parseName(tokens[1].text, origin), params, ttl}
+		if qtype == HTTPS {
+			return HTTPSRecord(record), nil
+		}
+		return record, nil
+
+	case OPT:
+		return nil, fmt.Errorf("OPT is a pseudo-record and has no presentation format")
+
+	default:
+		return nil, fmt.Errorf("unknown type needs RFC 3597 \\# RDATA")
+	}
+}
+
+func parseTypeList(tokens []zoneToken) ([]QueryType, error) {
+	types := make([]QueryType, 0, len(tokens))
+	for _, token := range tokens {
+		qtype, err := ParseQueryType(token.text)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, qtype)
+	}
+	return types, nil
+}
+
+func parseSvcParam(text string) (SvcParam, error) {
+	name, value, hasValue := strings.Cut(text, "=")
+	key, err := ParseSvcParamKey(name)
+	if err != nil {
+		return SvcParam{}, err
+	}
+	if hasValue {
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		if value, err = unescapeCharacterString(value); err != nil {
+			return SvcParam{}, err
+		}
+	}
+	if strings.HasPrefix(strings.ToLower(name), "key") {
+		// The generic form carries the wire value as is, even for keys
+		// with a structured presentation.
+		return SvcParam{Key: key, Value: []byte(value)}, nil
+	}
+
+	switch key {
+	case SvcParamMandatory:
+		keys := make([]SvcParamKey, 0)
+		for _, name := range splitValueList(value) {
+			k, err := ParseSvcParamKey(name)
+			if err != nil {
+				return SvcParam{}, err
+			}
+			keys = append(keys, k)
+		}
+		return NewMandatoryParam(keys), nil
+	case SvcParamALPN:
+		return NewALPNParam(splitValueList(value)), nil
+	case SvcParamNoDefaultALPN:
+		if hasValue {
+			return SvcParam{}, fmt.Errorf("no-default-alpn takes no value")
+		}
+		return SvcParam{Key: key}, nil
+	case SvcParamPort:
+		port, err := parseUint(value, 16)
+		if err != nil {
+			return SvcParam{}, err
+		}
+		return NewPortParam(uint16(port)), nil
+	case SvcParamIPv4Hint, SvcParamIPv6Hint:
+		addrs := make([]net.IP, 0)
+		for _, s := range splitValueList(value) {
+			addr := parseIPv6(s)
+			if key == SvcParamIPv4Hint {
+				addr = parseIPv4(s)
+			}
+			if addr == nil {
+				return SvcParam{}, fmt.Errorf("invalid %s address %q", key, s)
+			}
+			addrs = append(addrs, addr)
+		}
+		if key == SvcParamIPv4Hint {
+			return NewIPv4HintParam(addrs), nil
+		}
+		return NewIPv6HintParam(addrs), nil
+	case SvcParamECH:
+		config, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return SvcParam{}, fmt.Errorf("invalid ech: %s", err)
+		}
+		return NewECHParam(config), nil
+	default:
+		return SvcParam{Key: key, Value: []byte(value)}, nil
+	}
+}
+
+// splitValueList splits a comma-separated value list, honouring the "\,"
+// and "\\" escapes of RFC 9460 Appendix A.1 inside items.
+func splitValueList(s string) []string {
+	items := make([]string, 0)
+	var item strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			item.WriteByte(s[i])
+		case s[i] == ',':
+			items = append(items, item.String())
+			item.Reset()
+		default:
+			item.WriteByte(s[i])
+		}
+	}
+	return append(items, item.String())
+}
+
+// parseGenericRData decodes the RFC 3597 "\# len hex" form. Known types are
+// run through the wire decoder so the result is the typed record.
+func parseGenericRData(owner DomainName, ttl uint32, class Class, qtype QueryType, tokens []zoneToken) (DnsRecord, error) {
+	if len(tokens) < 1 {
+		return nil, fmt.Errorf("missing RDATA length")
+	}
+	length, err := parseUint(tokens[0].text, 16)
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(joinTokens(tokens[1:]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RDATA: %s", err)
+	}
+	if uint64(len(data)) != length {
+		return nil, fmt.Errorf("RDATA is %d bytes, expected %d", len(data), length)
+	}
+
+	return decodeRData(owner, ttl, class, qtype, data)
+}
+
+// decodeRData builds a record from raw RDATA by running it through the wire
+// decoder, so known types come back typed.
+func decodeRData(owner DomainName, ttl uint32, class Class, qtype QueryType, data []byte) (DnsRecord, error) {
+	buffer := NewBytePacketBufferSize(MaxTCPPacketSize)
+	pos, err := writeRecordHeader(buffer, owner, qtype, class, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if err := buffer.WriteBytes(data); err != nil {
+		return nil, err
+	}
+	if err := closeRecord(buffer, pos); err != nil {
+		return nil, err
+	}
+
+	wire, err := buffer.GetRange(0, buffer.Pos())
+	if err != nil {
+		return nil, err
+	}
+	reader := NewBytePacketBufferFrom(wire)
+	reader.SetStrict(true)
+	record, err := ReadDNSRecord(reader)
+	if err != nil {
+		return nil, err
+	}
+	if reader.Pos() != reader.Len() {
+		return nil, fmt.Errorf("RDATA has %d trailing bytes", reader.Len()-reader.Pos())
+	}
+	return record, nil
+}
@@ -0,0 +1,111 @@
+package dns
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// testRecords returns one record of every type the codec knows, with
+// values that exercise escaping and the less common encodings.
+func testRecords() []DnsRecord {
+	svcb := SVCBRecord{
+		Domain:   "_dns.example.com",
+		Class:    ClassIN,
+		Priority: 1,
+		Target:   "dns.example.com",
+		Params: []SvcParam{
+			NewMandatoryParam([]SvcParamKey{SvcParamALPN}),
+			NewALPNParam([]string{"h2", "h3"}),
+			NewPortParam(8443),
+			NewIPv4HintParam([]net.IP{net.IPv4(192, 0, 2, 1)}),
+			NewECHParam([]byte{0x01, 0x02, 0x03}),
+			NewIPv6HintParam([]net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("::ffff:192.0.2.1")}),
+		},
+		TTL: 300,
+	}
+
+	return []DnsRecord{
+		UnknownRecord{Domain: "example.com", Class: ClassIN, Type: 65280, Data: []byte{0xDE, 0xAD, 0xBE, 0xEF}, TTL: 300},
+		ARecord{Domain: "example.com", Class: ClassIN, Addr: net.IP{192, 0, 2, 1}, TTL: 300},
+		NSRecord{Domain: "example.com", Class: ClassIN, Host: "ns1.example.com", TTL: 300},
+		CNameRecord{Domain: "www.example.com", Class: ClassIN, Host: "example.com", TTL: 300},
+		SOARecord{Domain: "example.com", Class: ClassIN, MName: "ns1.example.com", RName: `host\.master.example.com`, Serial: 2024010101, Refresh: 7200, Retry: 3600, Expire: 1209600, Minimum: 300, TTL: 300},
+		PTRRecord{Domain: "1.2.0.192.in-addr.arpa", Class: ClassIN, Host: "example.com", TTL: 300},
+		DNAMERecord{Domain: "old.example.com", Class: ClassIN, Target: "new.example.com", TTL: 300},
+		MXRecord{Domain: "example.com", Class: ClassIN, Priority: 10, Host: "mail.example.com", TTL: 300},
+		AAAARecord{Domain: "example.com", Class: ClassIN, Addr: net.ParseIP("2001:db8::1"), TTL: 300},
+		AAAARecord{Domain: "mapped.example.com", Class: ClassIN, Addr: net.ParseIP("::ffff:1.2.3.4"), TTL: 300},
+		TXTRecord{Domain: "example.com", Class: ClassIN, Text: []string{"v=spf1 -all", `quote " and \ backslash`, "\x00\xff"}, TTL: 300},
+		SRVRecord{Domain: "_sip._tcp.example.com", Class: ClassIN, Priority: 10, Weight: 60, Port: 5060, Target: "sip.example.com", TTL: 300},
+		NAPTRRecord{Domain: "example.com", Class: ClassIN, Order: 100, Preference: 10, Flags: "S", Services: "SIP+D2U", Regexp: `!^.*$!sip:info@example.com!`, Replacement: "_sip._udp.example.com", TTL: 300},
+		SSHFPRecord{Domain: "host.example.com", Class: ClassIN, Algorithm: 4, FPType: 2, Fingerprint: []byte{0x12, 0x34, 0xAB, 0xCD}, TTL: 300},
+		TLSARecord{Domain: "_443._tcp.example.com", Class: ClassIN, Usage: 3, Selector: 1, MatchingType: 1, Data: []byte{0x0A, 0x0B, 0x0C}, TTL: 300},
+		CAARecord{Domain: "example.com", Class: ClassIN, Flags: 0, Tag: "issue", Value: "ca.example.net; account=230123", TTL: 300},
+		DSRecord{Domain: "example.com", Class: ClassIN, KeyTag: 60485, Algorithm: 5, DigestType: 1, Digest: []byte{0x2B, 0xB1, 0x83, 0xAF}, TTL: 300},
+		DNSKEYRecord{Domain: "example.com", Class: ClassIN, Flags: 257, Protocol: 3, Algorithm: 8, PublicKey: []byte{0x03, 0x01, 0x00, 0x01, 0xAB}, TTL: 300},
+		RRSIGRecord{Domain: "example.com", Class: ClassIN, TypeCovered: A, Algorithm: 8, Labels: 2, OriginalTTL: 300, Expiration: 1735689600, Inception: 1704067200, KeyTag: 60485, SignerName: "example.com", Signature: []byte{0x01, 0x02, 0x03, 0x04}, TTL: 300},
+		NSECRecord{Domain: "alpha.example.com", Class: ClassIN, NextDomain: "host.example.com", Types: []QueryType{A, MX, RRSIG, NSEC, QueryType(1234)}, TTL: 300},
+		NSEC3Record{Domain: "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom.example.com", Class: ClassIN, HashAlgorithm: 1, Flags: 1, Iterations: 12, Salt: []byte{0xAA, 0xBB, 0xCC, 0xDD}, NextHashed: []byte{0x01, 0x02, 0x03, 0x04, 0x05}, Types: []QueryType{A, RRSIG}, TTL: 300},
+		NSEC3PARAMRecord{Domain: "example.com", Class: ClassIN, HashAlgorithm: 1, Flags: 0, Iterations: 12, Salt: []byte{0xAA, 0xBB}, TTL: 300},
+		svcb,
+		HTTPSRecord(svcb),
+		ARecord{Domain: "chaos.example.com", Class: ClassCH, Addr: net.IP{192, 0, 2, 2}, TTL: 0},
+	}
+}
+
+func TestPrintParseRoundTrip(t *testing.T) {
+	for _, record := range testRecords() {
+		line := record.String()
+		parsed, err := ParseRecord(line, "")
+		if err != nil {
+			t.Errorf("ParseRecord(%q): %v", line, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed, record) {
+			t.Errorf("ParseRecord(%q) = %#v, want %#v", line, parsed, record)
+		}
+	}
+}
+
+func TestSvcParamPresentation(t *testing.T) {
+	tests := []struct {
+		param SvcParam
+		text  string
+	}{
+		{NewALPNParam([]string{"h2", "h3"}), `alpn=h2,h3`},
+		{NewALPNParam([]string{"h,2", "x"}), `alpn="h\\,2,x"`},
+		{NewALPNParam([]string{`f\oo,bar`, "h2"}), `alpn="f\\\\oo\\,bar,h2"`},
+		{SvcParam{Key: SvcParamPort, Value: []byte{1, 2, 3}}, `key3="\001\002\003"`},
+		{SvcParam{Key: SvcParamPort, Value: []byte{}}, `key3`},
+		{SvcParam{Key: SvcParamALPN, Value: []byte{5, 'h'}}, `key1="\005h"`},
+		{SvcParam{Key: SvcParamKey(667), Value: []byte("hello")}, `key667="hello"`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.param.String(); got != tt.text {
+			t.Errorf("SvcParam%v.String() = %s, want %s", tt.param.Value, got, tt.text)
+		}
+
+		record := SVCBRecord{Domain: "example.com", Class: ClassIN, Priority: 1, Target: "svc.example.com", Params: []SvcParam{tt.param}, TTL: 300}
+		line := record.String()
+		parsed, err := ParseRecord(line, "")
+		if err != nil {
+			t.Errorf("ParseRecord(%q): %v", line, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed, record) {
+			t.Errorf("ParseRecord(%q) = %#v, want %#v", line, parsed, record)
+		}
+	}
+
+	// RFC 9460 Appendix A.1 also allows the escapes without quotes.
+	record, err := ParseRecord(`example.com. 300 IN SVCB 1 svc.example.com. alpn=f\\\092oo\\,bar,h2`, "")
+	if err != nil {
+		t.Fatalf("ParseRecord: %v", err)
+	}
+	alpn, err := record.(SVCBRecord).Params[0].ALPN()
+	if want := []string{`f\oo,bar`, "h2"}; err != nil || !reflect.DeepEqual(alpn, want) {
+		t.Errorf("ALPN() = %q, %v, want %q", alpn, err, want)
+	}
+}
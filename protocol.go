@@ -1,19 +1,61 @@
-package main
+package dns
 
 import (
 	"fmt"
-	"strings"
+)
+
+// maxPointerOffset is the largest offset a 14-bit compression pointer can address.
+const maxPointerOffset = 0x3FFF
+
+// maxNameLength is the longest a domain name may be in wire format.
+const maxNameLength = 255
+
+const (
+	// MaxUDPPacketSize is the message size limit for UDP without EDNS0 (RFC 1035).
+	MaxUDPPacketSize = 512
+	// MaxEDNSPacketSize is the largest UDP payload we advertise or accept with EDNS0.
+	MaxEDNSPacketSize = 4096
+	// MaxTCPPacketSize is the largest message a 2-byte TCP length prefix can frame.
+	MaxTCPPacketSize = 65535
 )
 
 type BytePacketBuffer struct {
-	buf []byte
-	pos uint
+	// buf holds the bytes written or received so far; reads never go past
+	// len(buf). Writes grow it up to limit.
+	buf   []byte
+	pos   uint
+	limit uint
+
+	// names maps every name suffix written so far to its offset, so later
+	// occurrences can be emitted as compression pointers.
+	names map[string]uint
+
+	// strict makes the readers reject messages that are merely sloppy as
+	// well as those that cannot be decoded at all. See SetStrict.
+	strict bool
+}
+
+func NewBytePacketBuffer() *BytePacketBuffer {
+	return NewBytePacketBufferSize(MaxUDPPacketSize)
+}
+
+func NewBytePacketBufferSize(limit uint) *BytePacketBuffer {
+	return &BytePacketBuffer{
+		buf:   make([]byte, 0, limit),
+		pos:   0,
+		limit: limit,
+		names: make(map[string]uint),
+	}
 }
 
-func NewBytesPacketBuffer() *BytePacketBuffer {
+// NewBytePacketBufferFrom wraps a received message. Reads are bounded by
+// len(data) rather than by the capacity of the slice it came from.
+func NewBytePacketBufferFrom(data []byte) *BytePacketBuffer {
 	return &BytePacketBuffer{
-		buf: make([]byte, 512),
-		pos: 0,
+		buf:   data,
+		pos:   0,
+		limit: uint(len(data)),
+		names: make(map[string]uint),
 	}
 }
 
@@ -21,6 +63,27 @@ func (b *BytePacketBuffer) Pos() uint {
 	return b.pos
 }
 
+func (b *BytePacketBuffer) Len() uint {
+	return uint(len(b.buf))
+}
+
+func (b *BytePacketBuffer) Limit() uint {
+	return b.limit
+}
+
+// SetStrict turns strict parsing on or off. In strict mode compression
+// pointers must point backwards, each record must consume exactly RDLENGTH
+// bytes, the header counts must fit the message and no bytes may follow the
+// last record. Otherwise a record whose RDATA is shorter or longer than
+// RDLENGTH is skipped past rather than rejected.
+func (b *BytePacketBuffer) SetStrict(strict bool) {
+	b.strict = strict
+}
+
+func (b *BytePacketBuffer) Strict() bool {
+	return b.strict
+}
+
 func (b *BytePacketBuffer) Step(step uint) {
 	b.pos += step
 }
@@ -30,8 +93,8 @@ func (b *BytePacketBuffer) Seek(pos uint) {
 }
 
 func (b *BytePacketBuffer) Read() (byte, error) {
-	if b.pos >= 512 {
-		return 0, fmt.Errorf("Read: end of buffer")
+	if b.pos >= b.Len() {
+		return 0, fmt.Errorf("Read: %w", ErrEndOfBuffer)
 	}
 	res := b.buf[b.pos]
 	b.pos += 1
@@ -40,16 +103,16 @@ func (b *BytePacketBuffer) Read() (byte, error) {
 }
 
 func (b *BytePacketBuffer) Get(pos uint) (byte, error) {
-	if pos >= 512 {
-		return 0, fmt.Errorf("Get: end of buffer")
+	if pos >= b.Len() {
+		return 0, fmt.Errorf("Get: %w", ErrEndOfBuffer)
 	}
 
 	return b.buf[pos], nil
 }
 
 func (b *BytePacketBuffer) GetRange(start uint, len uint) ([]byte, error) {
-	if start+len >= 512 {
-		return nil, fmt.Errorf("Get range: end  of buffer")
+	if start+len > b.Len() {
+		return nil, fmt.Errorf("Get range: %w", ErrEndOfBuffer)
 	}
 
 	return b.buf[start : start+len], nil
@@ -58,11 +121,11 @@ func (b *BytePacketBuffer) GetRange(start uint, len uint) ([]byte, error) {
 func (b *BytePacketBuffer) ReadU16() (uint16, error) {
 	byte1, err := b.Read()
 	if err != nil {
-		return 0, fmt.Errorf("ReadU16: %s", err)
+		return 0, fmt.Errorf("ReadU16: %w", err)
 	}
 	byte2, err := b.Read()
 	if err != nil {
-		return 0, fmt.Errorf("ReadU16: %s", err)
+		return 0, fmt.Errorf("ReadU16: %w", err)
 	}
 	return (uint16(byte1) << 8) | uint16(byte2), nil
 }
@@ -70,41 +133,46 @@ func (b *BytePacketBuffer) ReadU16() (uint16, error) {
 func (b *BytePacketBuffer) ReadU32() (uint32, error) {
 	byte1, err := b.Read()
 	if err != nil {
-		return 0, fmt.Errorf("ReadU32: %s", err)
+		return 0, fmt.Errorf("ReadU32: %w", err)
 	}
 	byte2, err := b.Read()
 	if err != nil {
-		return 0, fmt.Errorf("ReadU32: %s", err)
+		return 0, fmt.Errorf("ReadU32: %w", err)
 	}
 	byte3, err := b.Read()
 	if err != nil {
-		return 0, fmt.Errorf("ReadU32: %s", err)
+		return 0, fmt.Errorf("ReadU32: %w", err)
 	}
 	byte4, err := b.Read()
 	if err != nil {
-		return 0, fmt.Errorf("ReadU32: %s", err)
+		return 0, fmt.Errorf("ReadU32: %w", err)
 	}
 	return (uint32(byte1) << 24) | (uint32(byte2) << 16) | (uint32(byte3) << 8) | uint32(byte4), nil
 }
 
-func (b *BytePacketBuffer) ReadQName(outString *string) error {
+func (b *BytePacketBuffer) ReadQName(outString *DomainName) error {
 
 	var pos = b.Pos()
 
 	var jumped bool = false
-	var maxJumps int = 5
+	// Every pointer a writer emits is followed by at least one label, and a
+	// name within maxNameLength has at most maxNameLength/2 of them, so any
+	// longer chain is a loop rather than compression.
+	var maxJumps int = maxNameLength / 2
 	var jumpsPerformed int = 0
-	var builder strings.Builder
-	var delimiter string = ""
+	// nameLength is the uncompressed wire length, counting every length
+	// octet and the final root label.
+	var nameLength uint = 1
+	var labels []string
 	for {
 
 		if jumpsPerformed > maxJumps {
-			return fmt.Errorf("Limit of %d jumps exceeded", maxJumps)
+			return fmt.Errorf("ReadQName: %w: limit of %d exceeded", ErrPointerLoop, maxJumps)
 		}
 
 		len, err := b.Get(pos)
 		if err != nil {
-			return err
+			return fmt.Errorf("ReadQName: %w", err)
 		}
 
 		if (len & 0xC0) == 0xC0 {
@@ -114,9 +182,14 @@ func (b *BytePacketBuffer) ReadQName(outString *string) error {
 
 			b2, err := b.Get(pos + 1)
 			if err != nil {
-				return fmt.Errorf("ReadQName: %s", err)
+				return fmt.Errorf("ReadQName: %w", err)
 			}
 			var offset = ((uint16(len) ^ 0xC0) << 8) | uint16(b2)
+			// RFC 1035 section 4.1.4: a pointer refers to a prior
+			// occurrence, so one at or past itself can only loop.
+			if b.strict && uint(offset) >= pos {
+				return fmt.Errorf("ReadQName: %w: offset %d at %d", ErrBadPointer, offset, pos)
+			}
 			pos = uint(offset)
 
 			jumped = true
@@ -124,6 +197,9 @@ func (b *BytePacketBuffer) ReadQName(outString *string) error {
 
 			continue
 
+		} else if (len & 0xC0) != 0 {
+			return fmt.Errorf("ReadQName: %w 0x%02X at %d", ErrLabelType, len&0xC0, pos)
+
 		} else {
 			pos += 1
 
@@ -131,17 +207,18 @@ func (b *BytePacketBuffer) ReadQName(outString *string) error {
 				break
 			}
 
-			builder.WriteString(delimiter)
+			nameLength += uint(len) + 1
+			if nameLength > maxNameLength {
+				return fmt.Errorf("ReadQName: %w", ErrNameTooLong)
+			}
 
 			strBuffer, err := b.GetRange(pos, uint(len))
 			if err != nil {
-				return fmt.Errorf("ReadQName: %s", err)
+				return fmt.Errorf("ReadQName: %w", err)
 
 			}
 
-			builder.Write(strBuffer)
-
-			delimiter = "."
+			labels = append(labels, string(strBuffer))
 
 			pos += uint(len)
 
@@ -151,15 +228,44 @@ func (b *BytePacketBuffer) ReadQName(outString *string) error {
 	if !jumped {
 		b.Seek(pos)
 	}
-	*outString = builder.String()
+	*outString = DomainNameFromLabels(labels)
 
 	return nil
 
 }
 
+// ReadBytes reads the next n bytes into a fresh slice.
+func (b *BytePacketBuffer) ReadBytes(n uint) ([]byte, error) {
+	data, err := b.GetRange(b.Pos(), n)
+	if err != nil {
+		return nil, fmt.Errorf("ReadBytes: %w", err)
+	}
+	b.Step(n)
+
+	return append([]byte(nil), data...), nil
+}
+
+// ReadCharacterString reads a single length-prefixed <character-string>.
+func (b *BytePacketBuffer) ReadCharacterString() (string, error) {
+	len, err := b.Read()
+	if err != nil {
+		return "", fmt.Errorf("ReadCharacterString: %w", err)
+	}
+
+	data, err := b.ReadBytes(uint(len))
+	if err != nil {
+		return "", fmt.Errorf("ReadCharacterString: %w", err)
+	}
+
+	return string(data), nil
+}
+
 func (b *BytePacketBuffer) Write(val uint8) error {
-	if b.pos >= 512 {
-		return fmt.Errorf("Write: end of buffer")
+	if b.pos >= b.limit {
+		return fmt.Errorf("Write: %w", ErrEndOfBuffer)
+	}
+	for b.Len() <= b.pos {
+		b.buf = append(b.buf, 0)
 	}
 	b.buf[b.pos] = val
 	b.pos += 1
@@ -192,13 +298,56 @@ func (b *BytePacketBuffer) WriteU32(val uint32) error {
 	return b.Write(uint8(val >> 0 & 0xFF))
 }
 
-func (b *BytePacketBuffer) WriteQName(qname *string) error {
-	for _, label := range strings.Split(*qname, ".") {
+// WriteQName writes qname, replacing the longest suffix already present in
+// the buffer with a compression pointer.
+func (b *BytePacketBuffer) WriteQName(qname *DomainName) error {
+	return b.writeQName(*qname, true)
+}
+
+// WriteQNameUncompressed writes qname in full. It is meant for names inside
+// the RDATA of types that RFC 3597 section 4 forbids compressing.
+func (b *BytePacketBuffer) WriteQNameUncompressed(qname *DomainName) error {
+	return b.writeQName(*qname, false)
+}
+
+// Unescaped non-ASCII characters in qname are taken as Unicode and written
+// as A-labels; see ToASCII.
+func (b *BytePacketBuffer) writeQName(qname DomainName, compress bool) error {
+	if !isASCII(string(qname)) {
+		ascii, err := ToASCII(string(qname))
+		if err != nil {
+			return err
+		}
+		qname = ascii
+	}
+
+	labels, err := qname.Labels()
+	if err != nil {
+		return err
+	}
+	if qname.WireLength() > maxNameLength {
+		return fmt.Errorf("Name %q exceeds %d bytes", qname, maxNameLength)
+	}
+
+	for i, label := range labels {
 		lenghtLable := len(label)
+		if lenghtLable == 0 {
+			return fmt.Errorf("Empty label in %q", qname)
+		}
 		if lenghtLable > 0x3F {
 			return fmt.Errorf("Single label exceeds 63 characters")
 		}
 
+		if compress {
+			suffix := string(DomainNameFromLabels(labels[i:]))
+			if offset, ok := b.names[suffix]; ok {
+				return b.WriteU16(0xC000 | uint16(offset))
+			}
+			if b.pos <= maxPointerOffset {
+				b.names[suffix] = b.pos
+			}
+		}
+
 		err := b.Write(uint8(lenghtLable))
 		if err != nil {
 			return err
@@ -213,8 +362,31 @@ func (b *BytePacketBuffer) WriteQName(qname *string) error {
 	return b.WriteU8(0)
 }
 
+func (b *BytePacketBuffer) WriteBytes(data []byte) error {
+	for _, c := range data {
+		if err := b.Write(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteCharacterString writes s as a length-prefixed <character-string>.
+func (b *BytePacketBuffer) WriteCharacterString(s string) error {
+	if len(s) > 0xFF {
+		return fmt.Errorf("Character string exceeds 255 bytes")
+	}
+
+	if err := b.WriteU8(uint8(len(s))); err != nil {
+		return err
+	}
+
+	return b.WriteBytes([]byte(s))
+}
+
 func (b *BytePacketBuffer) Set(pos uint, val uint8) error {
-	if pos >= 512 {
+	if pos >= b.Len() {
 		return fmt.Errorf("Set: end of buffer")
 	}
 	b.buf[pos] = val
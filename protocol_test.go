@@ -0,0 +1,107 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// roundTrip writes packet and reads it back with the given strictness.
+func roundTrip(t *testing.T, packet *DNSPacket, strict bool) (*DNSPacket, error) {
+	t.Helper()
+
+	buffer := NewBytePacketBufferSize(MaxTCPPacketSize)
+	if err := packet.Write(buffer); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	data, err := buffer.GetRange(0, buffer.Pos())
+	if err != nil {
+		t.Fatalf("GetRange: %v", err)
+	}
+
+	reader := NewBytePacketBufferFrom(data)
+	reader.SetStrict(strict)
+	return NewDNSPacket().Read(reader)
+}
+
+func TestCompressionChainRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		owners []DomainName
+	}{
+		{
+			name: "nested subdomains",
+			owners: []DomainName{
+				"example.com",
+				"b.example.com",
+				"c.b.example.com",
+				"d.c.b.example.com",
+				"e.d.c.b.example.com",
+				"f.e.d.c.b.example.com",
+				"g.f.e.d.c.b.example.com",
+				"h.g.f.e.d.c.b.example.com",
+			},
+		},
+		{
+			// Each name adds one label in front of the previous one, up to
+			// 127 labels and 255 octets, so the last owner is a chain of
+			// 126 pointers.
+			name: "longest possible chain",
+			owners: func() []DomainName {
+				owners := make([]DomainName, 0, maxNameLength/2)
+				for labels := 1; labels <= maxNameLength/2; labels++ {
+					owners = append(owners, DomainName(strings.TrimSuffix(strings.Repeat("a.", labels), ".")))
+				}
+				return owners
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			packet := NewDNSPacket()
+			for _, owner := range tt.owners {
+				packet.Answers = append(packet.Answers, ARecord{Domain: owner, Class: ClassIN, Addr: net.IPv4(192, 0, 2, 1), TTL: 60})
+			}
+
+			got, err := roundTrip(t, packet, strict)
+			if err != nil {
+				t.Fatalf("%s (strict %v): Read: %v", tt.name, strict, err)
+			}
+			if len(got.Answers) != len(tt.owners) {
+				t.Fatalf("%s (strict %v): got %d answers, want %d", tt.name, strict, len(got.Answers), len(tt.owners))
+			}
+			for i, answer := range got.Answers {
+				if owner := answer.(ARecord).Domain; owner != tt.owners[i] {
+					t.Errorf("%s (strict %v): answer %d owner %q, want %q", tt.name, strict, i, owner, tt.owners[i])
+				}
+			}
+		}
+	}
+}
+
+func TestReadQNamePointerLoop(t *testing.T) {
+	// A question whose name is a pointer to itself.
+	data := []byte{
+		0x00, 0x01, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xC0, 0x0C, 0x00, 0x01, 0x00, 0x01,
+	}
+
+	tests := []struct {
+		strict bool
+		want   error
+	}{
+		{false, ErrPointerLoop},
+		{true, ErrBadPointer},
+	}
+
+	for _, tt := range tests {
+		reader := NewBytePacketBufferFrom(data)
+		reader.SetStrict(tt.strict)
+		_, err := NewDNSPacket().Read(reader)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("strict %v: got %v, want %v", tt.strict, err, tt.want)
+		}
+	}
+}
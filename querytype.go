@@ -1,14 +1,38 @@
-package main
+package dns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 type QueryType uint16
 
 const (
-	UNKNOWN QueryType = iota
-	A       QueryType = 1
-	NS      QueryType = 2
-	CNAME   QueryType = 5
-	MX      QueryType = 15
-	AAAA    QueryType = 28
+	UNKNOWN    QueryType = iota
+	A          QueryType = 1
+	NS         QueryType = 2
+	CNAME      QueryType = 5
+	SOA        QueryType = 6
+	PTR        QueryType = 12
+	MX         QueryType = 15
+	TXT        QueryType = 16
+	AAAA       QueryType = 28
+	SRV        QueryType = 33
+	NAPTR      QueryType = 35
+	DNAME      QueryType = 39
+	OPT        QueryType = 41
+	DS         QueryType = 43
+	SSHFP      QueryType = 44
+	RRSIG      QueryType = 46
+	NSEC       QueryType = 47
+	DNSKEY     QueryType = 48
+	NSEC3      QueryType = 50
+	NSEC3PARAM QueryType = 51
+	TLSA       QueryType = 52
+	SVCB       QueryType = 64
+	HTTPS      QueryType = 65
+	CAA        QueryType = 257
 )
 
 func (qt QueryType) String() string {
@@ -19,11 +43,71 @@ func (qt QueryType) String() string {
 		return "NS"
 	case CNAME:
 		return "CNAME"
+	case SOA:
+		return "SOA"
+	case PTR:
+		return "PTR"
 	case MX:
 		return "MX"
+	case TXT:
+		return "TXT"
 	case AAAA:
 		return "AAAA"
-	default:
+	case SRV:
+		return "SRV"
+	case NAPTR:
+		return "NAPTR"
+	case DNAME:
+		return "DNAME"
+	case OPT:
+		return "OPT"
+	case DS:
+		return "DS"
+	case SSHFP:
+		return "SSHFP"
+	case RRSIG:
+		return "RRSIG"
+	case NSEC:
+		return "NSEC"
+	case DNSKEY:
+		return "DNSKEY"
+	case NSEC3:
+		return "NSEC3"
+	case NSEC3PARAM:
+		return "NSEC3PARAM"
+	case TLSA:
+		return "TLSA"
+	case SVCB:
+		return "SVCB"
+	case HTTPS:
+		return "HTTPS"
+	case CAA:
+		return "CAA"
+	case UNKNOWN:
 		return "UNKNOWN"
+	default:
+		return fmt.Sprintf("TYPE%d", uint16(qt))
 	}
 }
+
+// knownQueryTypes lists every type with a mnemonic, for ParseQueryType.
+var knownQueryTypes = []QueryType{A, NS, CNAME, SOA, PTR, MX, TXT, AAAA, SRV, NAPTR, DNAME, OPT, DS, SSHFP, RRSIG, NSEC, DNSKEY, NSEC3, NSEC3PARAM, TLSA, SVCB, HTTPS, CAA}
+
+// ParseQueryType accepts a type mnemonic such as "MX" or the RFC 3597
+// generic form "TYPE123".
+func ParseQueryType(s string) (QueryType, error) {
+	upper := strings.ToUpper(s)
+	for _, qt := range knownQueryTypes {
+		if qt.String() == upper {
+			return qt, nil
+		}
+	}
+
+	if strings.HasPrefix(upper, "TYPE") {
+		if n, err := strconv.ParseUint(upper[4:], 10, 16); err == nil {
+			return QueryType(n), nil
+		}
+	}
+
+	return UNKNOWN, fmt.Errorf("ParseQueryType: unknown type %q", s)
+}
@@ -1,5 +1,10 @@
-package main
+package dns
 
+import "fmt"
+
+// ResultCode holds an RCODE. Values above 15 are extended RCODEs (RFC 6891
+// section 6.1.3): the header carries the low 4 bits and the OPT record the
+// upper 8, see DNSPacket.RCode and DNSPacket.SetRCode.
 type ResultCode int
 
 const (
@@ -9,4 +14,103 @@ const (
 	NXDOMAIN
 	NOTIMP
 	REFUSED
+	YXDOMAIN
+	YXRRSET
+	NXRRSET
+	NOTAUTH
+	NOTZONE
+	DSOTYPENI
+)
+
+const (
+	BADVERS ResultCode = iota + 16 // 16
+	BADKEY  ResultCode = iota + 16
+	BADTIME
+	BADMODE
+	BADNAME
+	BADALG
+	BADTRUNC
+	BADCOOKIE
 )
+
+// BADSIG shares its value with BADVERS; it appears in TSIG records rather
+// than in the OPT record (RFC 8945).
+const BADSIG = BADVERS
+
+func (rc ResultCode) String() string {
+	switch rc {
+	case NOERROR:
+		return "NOERROR"
+	case FORMERR:
+		return "FORMERR"
+	case SERVFAIL:
+		return "SERVFAIL"
+	case NXDOMAIN:
+		return "NXDOMAIN"
+	case NOTIMP:
+		return "NOTIMP"
+	case REFUSED:
+		return "REFUSED"
+	case YXDOMAIN:
+		return "YXDOMAIN"
+	case YXRRSET:
+		return "YXRRSET"
+	case NXRRSET:
+		return "NXRRSET"
+	case NOTAUTH:
+		return "NOTAUTH"
+	case NOTZONE:
+		return "NOTZONE"
+	case DSOTYPENI:
+		return "DSOTYPENI"
+	case BADVERS:
+		return "BADVERS"
+	case BADKEY:
+		return "BADKEY"
+	case BADTIME:
+		return "BADTIME"
+	case BADMODE:
+		return "BADMODE"
+	case BADNAME:
+		return "BADNAME"
+	case BADALG:
+		return "BADALG"
+	case BADTRUNC:
+		return "BADTRUNC"
+	case BADCOOKIE:
+		return "BADCOOKIE"
+	default:
+		return fmt.Sprintf("RCODE%d", int(rc))
+	}
+}
+
+// Opcode is the kind of query in the header (RFC 1035 section 4.1.1).
+type Opcode uint8
+
+const (
+	QUERY  Opcode = 0
+	IQUERY Opcode = 1
+	STATUS Opcode = 2
+	NOTIFY Opcode = 4
+	UPDATE Opcode = 5
+	DSO    Opcode = 6
+)
+
+func (op Opcode) String() string {
+	switch op {
+	case QUERY:
+		return "QUERY"
+	case IQUERY:
+		return "IQUERY"
+	case STATUS:
+		return "STATUS"
+	case NOTIFY:
+		return "NOTIFY"
+	case UPDATE:
+		return "UPDATE"
+	case DSO:
+		return "DSO"
+	default:
+		return fmt.Sprintf("OPCODE%d", uint8(op))
+	}
+}
@@ -0,0 +1,118 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	reverseIPv4Suffix = ".in-addr.arpa"
+	reverseIPv6Suffix = ".ip6.arpa"
+)
+
+// ReverseName returns the in-addr.arpa or nibble-format ip6.arpa name used
+// to look up PTR records for ip.
+func ReverseName(ip net.IP) (DomainName, error) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return DomainName(fmt.Sprintf("%d.%d.%d.%d%s", ip4[3], ip4[2], ip4[1], ip4[0], reverseIPv4Suffix)), nil
+	}
+
+	ip6 := ip.To16()
+	if ip6 == nil {
+		return "", fmt.Errorf("ReverseName: invalid IP address %v", ip)
+	}
+
+	const hexDigits = "0123456789abcdef"
+	var builder strings.Builder
+	for i := len(ip6) - 1; i >= 0; i-- {
+		builder.WriteByte(hexDigits[ip6[i]&0x0F])
+		builder.WriteByte('.')
+		builder.WriteByte(hexDigits[ip6[i]>>4])
+		builder.WriteByte('.')
+	}
+	builder.WriteString(strings.TrimPrefix(reverseIPv6Suffix, "."))
+
+	return DomainName(builder.String()), nil
+}
+
+// ParseReverseName converts a reverse name back into the network it
+// covers. A full name yields a /32 or /128; a shorter one such as
+// "168.192.in-addr.arpa" yields the matching prefix.
+func ParseReverseName(name DomainName) (*net.IPNet, error) {
+	lower := string(name.Canonical())
+
+	switch {
+	case strings.HasSuffix(lower, reverseIPv4Suffix):
+		return parseReverseIPv4(strings.TrimSuffix(lower, reverseIPv4Suffix))
+	case strings.HasSuffix(lower, reverseIPv6Suffix):
+		return parseReverseIPv6(strings.TrimSuffix(lower, reverseIPv6Suffix))
+	default:
+		return nil, fmt.Errorf("ParseReverseName: %q is not a reverse name", name)
+	}
+}
+
+// ParseReverseIP is like ParseReverseName but only accepts names that
+// identify a single address.
+func ParseReverseIP(name DomainName) (net.IP, error) {
+	network, err := ParseReverseName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ones, bits := network.Mask.Size()
+	if ones != bits {
+		return nil, fmt.Errorf("ParseReverseIP: %q covers a /%d prefix, not a single address", name, ones)
+	}
+
+	return network.IP, nil
+}
+
+func parseReverseIPv4(labels string) (*net.IPNet, error) {
+	parts := strings.Split(labels, ".")
+	if labels == "" || len(parts) > net.IPv4len {
+		return nil, fmt.Errorf("parseReverseIPv4: invalid label count in %q", labels)
+	}
+
+	ip := make(net.IP, net.IPv4len)
+	for i, part := range parts {
+		if len(part) > 1 && part[0] == '0' {
+			return nil, fmt.Errorf("parseReverseIPv4: invalid octet %q", part)
+		}
+		octet, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("parseReverseIPv4: invalid octet %q", part)
+		}
+		ip[len(parts)-1-i] = byte(octet)
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(len(parts)*8, 32)}, nil
+}
+
+func parseReverseIPv6(labels string) (*net.IPNet, error) {
+	parts := strings.Split(labels, ".")
+	if labels == "" || len(parts) > net.IPv6len*2 {
+		return nil, fmt.Errorf("parseReverseIPv6: invalid label count in %q", labels)
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	for i, part := range parts {
+		if len(part) != 1 {
+			return nil, fmt.Errorf("parseReverseIPv6: invalid nibble %q", part)
+		}
+		nibble, err := strconv.ParseUint(part, 16, 4)
+		if err != nil {
+			return nil, fmt.Errorf("parseReverseIPv6: invalid nibble %q", part)
+		}
+
+		index := len(parts) - 1 - i
+		if index%2 == 0 {
+			ip[index/2] |= byte(nibble) << 4
+		} else {
+			ip[index/2] |= byte(nibble)
+		}
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(len(parts)*4, 128)}, nil
+}
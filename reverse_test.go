@@ -0,0 +1,86 @@
+package dns
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestReverseNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		ip   string
+		name DomainName
+	}{
+		{"192.0.2.1", "1.2.0.192.in-addr.arpa"},
+		{"10.0.0.255", "255.0.0.10.in-addr.arpa"},
+		{"0.0.0.0", "0.0.0.0.in-addr.arpa"},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+		{"::1", DomainName("1." + strings.Repeat("0.", 31) + "ip6.arpa")},
+	}
+
+	for _, tt := range tests {
+		ip := net.ParseIP(tt.ip)
+		name, err := ReverseName(ip)
+		if err != nil || name != tt.name {
+			t.Errorf("ReverseName(%s) = %q, %v, want %q", tt.ip, name, err, tt.name)
+			continue
+		}
+
+		got, err := ParseReverseIP(name)
+		if err != nil || !got.Equal(ip) {
+			t.Errorf("ParseReverseIP(%q) = %v, %v, want %s", name, got, err, tt.ip)
+		}
+
+		// Case and a trailing dot do not matter.
+		upper := DomainName(strings.ToUpper(string(name)) + ".")
+		if got, err := ParseReverseIP(upper); err != nil || !got.Equal(ip) {
+			t.Errorf("ParseReverseIP(%q) = %v, %v, want %s", upper, got, err, tt.ip)
+		}
+	}
+}
+
+func TestParseReverseNamePrefix(t *testing.T) {
+	tests := []struct {
+		name DomainName
+		cidr string
+	}{
+		{"168.192.in-addr.arpa", "192.168.0.0/16"},
+		{"10.in-addr.arpa", "10.0.0.0/8"},
+		{"8.b.d.0.1.0.0.2.ip6.arpa", "2001:db8::/32"},
+		{"0.8.b.d.0.1.0.0.2.ip6.arpa", "2001:db8::/36"},
+	}
+	for _, tt := range tests {
+		_, want, _ := net.ParseCIDR(tt.cidr)
+		got, err := ParseReverseName(tt.name)
+		if err != nil || got.String() != want.String() {
+			t.Errorf("ParseReverseName(%q) = %v, %v, want %v", tt.name, got, err, want)
+		}
+	}
+
+	if ip, err := ParseReverseIP("168.192.in-addr.arpa"); err == nil {
+		t.Errorf("ParseReverseIP of a /16 = %v, want an error", ip)
+	}
+}
+
+func TestParseReverseNameRejectsBadInput(t *testing.T) {
+	for _, name := range []DomainName{
+		"1.2.0.192.example.com",                           // wrong suffix
+		"in-addr.arpa",                                    // no labels
+		"5.1.2.0.192.in-addr.arpa",                        // five octets
+		"256.2.0.192.in-addr.arpa",                        // octet out of range
+		"01.2.0.192.in-addr.arpa",                         // leading zero
+		"a.2.0.192.in-addr.arpa",                          // not a number
+		"g.8.b.d.0.1.0.0.2.ip6.arpa",                      // non-hex nibble
+		"10.8.b.d.0.1.0.0.2.ip6.arpa",                     // two-digit nibble
+		".8.b.d.0.1.0.0.2.ip6.arpa",                       // empty nibble
+		DomainName(strings.Repeat("0.", 33) + "ip6.arpa"), // 33 nibbles
+	} {
+		if network, err := ParseReverseName(name); err == nil {
+			t.Errorf("ParseReverseName(%q) = %v, want an error", name, network)
+		}
+	}
+
+	if name, err := ReverseName(net.IP{1, 2, 3}); err == nil {
+		t.Errorf("ReverseName of a 3-byte IP = %q, want an error", name)
+	}
+}
@@ -0,0 +1,352 @@
+package dns
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type SvcParamKey uint16
+
+const (
+	SvcParamMandatory     SvcParamKey = 0
+	SvcParamALPN          SvcParamKey = 1
+	SvcParamNoDefaultALPN SvcParamKey = 2
+	SvcParamPort          SvcParamKey = 3
+	SvcParamIPv4Hint      SvcParamKey = 4
+	SvcParamECH           SvcParamKey = 5
+	SvcParamIPv6Hint      SvcParamKey = 6
+)
+
+func (key SvcParamKey) String() string {
+	switch key {
+	case SvcParamMandatory:
+		return "mandatory"
+	case SvcParamALPN:
+		return "alpn"
+	case SvcParamNoDefaultALPN:
+		return "no-default-alpn"
+	case SvcParamPort:
+		return "port"
+	case SvcParamIPv4Hint:
+		return "ipv4hint"
+	case SvcParamECH:
+		return "ech"
+	case SvcParamIPv6Hint:
+		return "ipv6hint"
+	default:
+		return fmt.Sprintf("key%d", uint16(key))
+	}
+}
+
+func ParseSvcParamKey(s string) (SvcParamKey, error) {
+	lower := strings.ToLower(s)
+	for key := SvcParamMandatory; key <= SvcParamIPv6Hint; key++ {
+		if key.String() == lower {
+			return key, nil
+		}
+	}
+
+	if strings.HasPrefix(lower, "key") {
+		if n, err := strconv.ParseUint(lower[3:], 10, 16); err == nil {
+			return SvcParamKey(n), nil
+		}
+	}
+
+	return 0, fmt.Errorf("ParseSvcParamKey: unknown key %q", s)
+}
+
+// SvcParam is a single SvcParamKey=SvcParamValue pair from RFC 9460. Value
+// holds the wire form; the typed accessors below decode it.
+type SvcParam struct {
+	Key   SvcParamKey
+	Value []byte
+}
+
+func NewMandatoryParam(keys []SvcParamKey) SvcParam {
+	sorted := append([]SvcParamKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	value := make([]byte, 0, 2*len(sorted))
+	for _, key := range sorted {
+		value = append(value, byte(key>>8), byte(key))
+	}
+	return SvcParam{Key: SvcParamMandatory, Value: value}
+}
+
+func NewALPNParam(protocols []string) SvcParam {
+	value := make([]byte, 0)
+	for _, protocol := range protocols {
+		value = append(value, byte(len(protocol)))
+		value = append(value, protocol...)
+	}
+	return SvcParam{Key: SvcParamALPN, Value: value}
+}
+
+func NewPortParam(port uint16) SvcParam {
+	return SvcParam{Key: SvcParamPort, Value: []byte{byte(port >> 8), byte(port)}}
+}
+
+func NewIPv4HintParam(addrs []net.IP) SvcParam {
+	value := make([]byte, 0, net.IPv4len*len(addrs))
+	for _, addr := range addrs {
+		value = append(value, addr.To4()...)
+	}
+	return SvcParam{Key: SvcParamIPv4Hint, Value: value}
+}
+
+func NewIPv6HintParam(addrs []net.IP) SvcParam {
+	value := make([]byte, 0, net.IPv6len*len(addrs))
+	for _, addr := range addrs {
+		value = append(value, addr.To16()...)
+	}
+	return SvcParam{Key: SvcParamIPv6Hint, Value: value}
+}
+
+func NewECHParam(config []byte) SvcParam {
+	return SvcParam{Key: SvcParamECH, Value: append([]byte(nil), config...)}
+}
+
+func (param SvcParam) Mandatory() ([]SvcParamKey, error) {
+	if param.Key != SvcParamMandatory || len(param.Value) == 0 || len(param.Value)%2 != 0 {
+		return nil, fmt.Errorf("SvcParam.Mandatory: malformed %s value", param.Key)
+	}
+
+	keys := make([]SvcParamKey, 0, len(param.Value)/2)
+	for i := 0; i < len(param.Value); i += 2 {
+		keys = append(keys, SvcParamKey(uint16(param.Value[i])<<8|uint16(param.Value[i+1])))
+	}
+	return keys, nil
+}
+
+func (param SvcParam) ALPN() ([]string, error) {
+	if param.Key != SvcParamALPN || len(param.Value) == 0 {
+		return nil, fmt.Errorf("SvcParam.ALPN: malformed %s value", param.Key)
+	}
+
+	protocols := make([]string, 0)
+	for i := 0; i < len(param.Value); {
+		length := int(param.Value[i])
+		i++
+		if length == 0 || i+length > len(param.Value) {
+			return nil, fmt.Errorf("SvcParam.ALPN: malformed protocol id")
+		}
+		protocols = append(protocols, string(param.Value[i:i+length]))
+		i += length
+	}
+	return protocols, nil
+}
+
+func (param SvcParam) Port() (uint16, error) {
+	if param.Key != SvcParamPort || len(param.Value) != 2 {
+		return 0, fmt.Errorf("SvcParam.Port: malformed %s value", param.Key)
+	}
+	return uint16(param.Value[0])<<8 | uint16(param.Value[1]), nil
+}
+
+// IPHints decodes an ipv4hint or ipv6hint value.
+func (param SvcParam) IPHints() ([]net.IP, error) {
+	size := 0
+	switch param.Key {
+	case SvcParamIPv4Hint:
+		size = net.IPv4len
+	case SvcParamIPv6Hint:
+		size = net.IPv6len
+	}
+	if size == 0 || len(param.Value) == 0 || len(param.Value)%size != 0 {
+		return nil, fmt.Errorf("SvcParam.IPHints: malformed %s value", param.Key)
+	}
+
+	addrs := make([]net.IP, 0, len(param.Value)/size)
+	for i := 0; i < len(param.Value); i += size {
+		addrs = append(addrs, net.IP(append([]byte(nil), param.Value[i:i+size]...)))
+	}
+	return addrs, nil
+}
+
+func (param SvcParam) String() string {
+	switch param.Key {
+	case SvcParamMandatory:
+		if keys, err := param.Mandatory(); err == nil {
+			names := make([]string, 0, len(keys))
+			for _, key := range keys {
+				names = append(names, key.String())
+			}
+			return fmt.Sprintf("%s=%s", param.Key, strings.Join(names, ","))
+		}
+	case SvcParamALPN:
+		if protocols, err := param.ALPN(); err == nil {
+			escaped := make([]string, 0, len(protocols))
+			for _, protocol := range protocols {
+				escaped = append(escaped, escapeValueListItem(protocol))
+			}
+			return fmt.Sprintf("%s=%s", param.Key, formatSvcValue(strings.Join(escaped, ",")))
+		}
+	case SvcParamNoDefaultALPN:
+		if len(param.Value) == 0 {
+			return param.Key.String()
+		}
+	case SvcParamPort:
+		if port, err := param.Port(); err == nil {
+			return fmt.Sprintf("%s=%d", param.Key, port)
+		}
+	case SvcParamIPv4Hint, SvcParamIPv6Hint:
+		if addrs, err := param.IPHints(); err == nil {
+			names := make([]string, 0, len(addrs))
+			for _, addr := range addrs {
+				if param.Key == SvcParamIPv6Hint {
+					names = append(names, formatIPv6(addr))
+				} else {
+					names = append(names, addr.String())
+				}
+			}
+			return fmt.Sprintf("%s=%s", param.Key, strings.Join(names, ","))
+		}
+	case SvcParamECH:
+		return fmt.Sprintf("%s=%s", param.Key, base64.StdEncoding.EncodeToString(param.Value))
+	}
+
+	// Values that do not decode for their key use the generic keyNNNNN
+	// form, which parseSvcParam reads back as opaque bytes.
+	generic := fmt.Sprintf("key%d", uint16(param.Key))
+	if len(param.Value) == 0 {
+		return generic
+	}
+	return fmt.Sprintf("%s=%s", generic, quoteCharacterString(string(param.Value)))
+}
+
+// escapeValueListItem escapes the commas and backslashes inside one item of
+// a comma-separated value list (RFC 9460 Appendix A.1).
+func escapeValueListItem(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`).Replace(s)
+}
+
+// formatSvcValue leaves a value bare when a zone file can carry it as a
+// single token and quotes it otherwise.
+func formatSvcValue(s string) string {
+	for _, c := range []byte(s) {
+		if c <= ' ' || c > '~' || strings.IndexByte(`"\;()`, c) >= 0 {
+			return quoteCharacterString(s)
+		}
+	}
+	return s
+}
+
+type SVCBRecord struct {
+	Domain   DomainName
+	Class    Class
+	Priority uint16
+	Target   DomainName
+	Params   []SvcParam
+	TTL      uint32
+}
+
+func (SVCBRecord) isDnsRecord() {}
+
+func (record SVCBRecord) Name() string {
+	return "SVCB"
+}
+
+func (record SVCBRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, SVCB, formatSVCB(record))
+}
+
+// HTTPSRecord shares the SVCB wire format and differs only in its type code.
+type HTTPSRecord SVCBRecord
+
+func (HTTPSRecord) isDnsRecord() {}
+
+func (record HTTPSRecord) Name() string {
+	return "HTTPS"
+}
+
+func (record HTTPSRecord) String() string {
+	return formatRecord(record.Domain, record.TTL, record.Class, HTTPS, formatSVCB(SVCBRecord(record)))
+}
+
+func formatSVCB(record SVCBRecord) string {
+	fields := []string{fmt.Sprint(record.Priority), fqdn(record.Target)}
+	for _, param := range record.Params {
+		fields = append(fields, param.String())
+	}
+	return strings.Join(fields, " ")
+}
+
+func readSVCBRecord(buffer *BytePacketBuffer, domain DomainName, class Class, ttl uint32, dataLength uint16) (SVCBRecord, error) {
+	start := buffer.Pos()
+	end := start + uint(dataLength)
+	record := SVCBRecord{Domain: domain, Class: class, TTL: ttl, Params: make([]SvcParam, 0)}
+
+	var err error
+	if record.Priority, err = buffer.ReadU16(); err != nil {
+		return record, fmt.Errorf("readSVCBRecord.ReadU16.priority: %w", err)
+	}
+	if err := buffer.ReadQName(&record.Target); err != nil {
+		return record, fmt.Errorf("readSVCBRecord.ReadQName.target: %w", err)
+	}
+
+	for buffer.Pos() < end {
+		key, err := buffer.ReadU16()
+		if err != nil {
+			return record, fmt.Errorf("readSVCBRecord.ReadU16.key: %w", err)
+		}
+		if n := len(record.Params); n > 0 && SvcParamKey(key) <= record.Params[n-1].Key {
+			return record, fmt.Errorf("readSVCBRecord: SvcParamKeys not in strictly increasing order")
+		}
+
+		length, err := buffer.ReadU16()
+		if err != nil {
+			return record, fmt.Errorf("readSVCBRecord.ReadU16.length: %w", err)
+		}
+		value, err := buffer.ReadBytes(uint(length))
+		if err != nil {
+			return record, fmt.Errorf("readSVCBRecord.ReadBytes.value: %w", err)
+		}
+
+		record.Params = append(record.Params, SvcParam{Key: SvcParamKey(key), Value: value})
+	}
+
+	if buffer.Pos() != end {
+		return record, fmt.Errorf("readSVCBRecord: SvcParams overrun RDATA")
+	}
+
+	return record, nil
+}
+
+func writeSVCBRecord(buffer *BytePacketBuffer, qtype QueryType, record SVCBRecord) error {
+	pos, err := writeRecordHeader(buffer, record.Domain, qtype, record.Class, record.TTL)
+	if err != nil {
+		return fmt.Errorf("writeSVCBRecord.%w", err)
+	}
+
+	if err := buffer.WriteU16(record.Priority); err != nil {
+		return fmt.Errorf("writeSVCBRecord.WriteU16.priority: %w", err)
+	}
+	// RFC 9460 section 2.2: TargetName is never compressed.
+	if err := buffer.WriteQNameUncompressed(&record.Target); err != nil {
+		return fmt.Errorf("writeSVCBRecord.WriteQNameUncompressed.target: %w", err)
+	}
+
+	params := append([]SvcParam(nil), record.Params...)
+	sort.SliceStable(params, func(i, j int) bool { return params[i].Key < params[j].Key })
+
+	for i, param := range params {
+		if i > 0 && param.Key == params[i-1].Key {
+			return fmt.Errorf("writeSVCBRecord: duplicate SvcParamKey %s", param.Key)
+		}
+		if err := buffer.WriteU16(uint16(param.Key)); err != nil {
+			return fmt.Errorf("writeSVCBRecord.WriteU16.key: %w", err)
+		}
+		if err := buffer.WriteU16(uint16(len(param.Value))); err != nil {
+			return fmt.Errorf("writeSVCBRecord.WriteU16.length: %w", err)
+		}
+		if err := buffer.WriteBytes(param.Value); err != nil {
+			return fmt.Errorf("writeSVCBRecord.WriteBytes.value: %w", err)
+		}
+	}
+
+	return closeRecord(buffer, pos)
+}
@@ -0,0 +1,144 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Truncate shrinks d until it encodes in limit bytes, following RFC 2181
+// section 9. Whole RRsets are dropped from the end of the additional
+// section, keeping the OPT record, and then from the end of the authority
+// section; neither sets TC. If the answer still does not fit, TC is set and
+// only the question and the OPT record are kept, so the client retries over
+// TCP.
+func (d *DNSPacket) Truncate(limit uint) error {
+	for {
+		fits, err := d.fits(limit)
+		if err != nil {
+			return fmt.Errorf("DNSPacket.Truncate: %w", err)
+		}
+		if fits {
+			return nil
+		}
+
+		if !dropLastRRset(&d.Resources) && !dropLastRRset(&d.Authorities) {
+			break
+		}
+	}
+
+	d.Header.TruncatedMessage = true
+	d.Answers = make([]DnsRecord, 0)
+	d.Authorities = make([]DnsRecord, 0)
+	if opt, ok := d.GetOPT(); ok {
+		d.Resources = []DnsRecord{opt}
+	} else {
+		d.Resources = make([]DnsRecord, 0)
+	}
+
+	fits, err := d.fits(limit)
+	if err != nil {
+		return fmt.Errorf("DNSPacket.Truncate: %w", err)
+	}
+	if !fits {
+		return fmt.Errorf("DNSPacket.Truncate: question does not fit in %d bytes", limit)
+	}
+	return nil
+}
+
+// fits reports whether d encodes in limit bytes.
+func (d *DNSPacket) fits(limit uint) (bool, error) {
+	err := d.Write(NewBytePacketBufferSize(limit))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrEndOfBuffer):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// dropLastRRset removes every record of the RRset that the last record in
+// section belongs to, skipping OPT. It reports whether anything was removed.
+func dropLastRRset(section *[]DnsRecord) bool {
+	records := *section
+	last := len(records) - 1
+	for last >= 0 {
+		if _, ok := records[last].(OPTRecord); !ok {
+			break
+		}
+		last--
+	}
+	if last < 0 {
+		return false
+	}
+
+	owner, qtype, class := rrsetKey(records[last])
+	kept := make([]DnsRecord, 0, len(records))
+	for _, record := range records {
+		o, t, c := rrsetKey(record)
+		if t == qtype && c == class && o.Equal(owner) {
+			continue
+		}
+		kept = append(kept, record)
+	}
+
+	*section = kept
+	return true
+}
+
+// rrsetKey returns the owner, type and class that group a record into its
+// RRset. An RRSIG is keyed by the type it covers, so it is kept or dropped
+// together with the RRset it signs.
+func rrsetKey(record DnsRecord) (DomainName, QueryType, Class) {
+	switch record := record.(type) {
+	case UnknownRecord:
+		return record.Domain, QueryType(record.Type), record.Class
+	case ARecord:
+		return record.Domain, A, record.Class
+	case NSRecord:
+		return record.Domain, NS, record.Class
+	case CNameRecord:
+		return record.Domain, CNAME, record.Class
+	case SOARecord:
+		return record.Domain, SOA, record.Class
+	case PTRRecord:
+		return record.Domain, PTR, record.Class
+	case DNAMERecord:
+		return record.Domain, DNAME, record.Class
+	case MXRecord:
+		return record.Domain, MX, record.Class
+	case AAAARecord:
+		return record.Domain, AAAA, record.Class
+	case TXTRecord:
+		return record.Domain, TXT, record.Class
+	case SRVRecord:
+		return record.Domain, SRV, record.Class
+	case NAPTRRecord:
+		return record.Domain, NAPTR, record.Class
+	case SSHFPRecord:
+		return record.Domain, SSHFP, record.Class
+	case TLSARecord:
+		return record.Domain, TLSA, record.Class
+	case CAARecord:
+		return record.Domain, CAA, record.Class
+	case DSRecord:
+		return record.Domain, DS, record.Class
+	case DNSKEYRecord:
+		return record.Domain, DNSKEY, record.Class
+	case RRSIGRecord:
+		return record.Domain, record.TypeCovered, record.Class
+	case NSECRecord:
+		return record.Domain, NSEC, record.Class
+	case NSEC3Record:
+		return record.Domain, NSEC3, record.Class
+	case NSEC3PARAMRecord:
+		return record.Domain, NSEC3PARAM, record.Class
+	case SVCBRecord:
+		return record.Domain, SVCB, record.Class
+	case HTTPSRecord:
+		return record.Domain, HTTPS, record.Class
+	default:
+		return "", OPT, 0
+	}
+}
@@ -0,0 +1,109 @@
+package dns
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	question := NewDNSQuestion("example.com", A)
+	opt := NewOPTRecord(1232, true)
+	answer := ARecord{Domain: "example.com", Class: ClassIN, Addr: net.IP{192, 0, 2, 1}, TTL: 300}
+	ns := NSRecord{Domain: "example.com", Class: ClassIN, Host: "ns1.example.com", TTL: 300}
+	glueA1 := ARecord{Domain: "ns1.example.com", Class: ClassIN, Addr: net.IP{192, 0, 2, 53}, TTL: 300}
+	glueAAAA := AAAARecord{Domain: "ns1.example.com", Class: ClassIN, Addr: net.ParseIP("2001:db8::53"), TTL: 300}
+	glueA2 := ARecord{Domain: "ns1.example.com", Class: ClassIN, Addr: net.IP{192, 0, 2, 54}, TTL: 300}
+
+	build := func(answers, authorities, resources []DnsRecord) *DNSPacket {
+		packet := NewDNSPacket()
+		packet.Questions = append(packet.Questions, question)
+		packet.Answers = append(packet.Answers, answers...)
+		packet.Authorities = append(packet.Authorities, authorities...)
+		packet.Resources = append(packet.Resources, resources...)
+		return packet
+	}
+	full := func() *DNSPacket {
+		// The last additional record belongs to the A RRset of ns1, so
+		// the first RRset to go is both glue A records at once.
+		return build([]DnsRecord{answer}, []DnsRecord{ns}, []DnsRecord{glueA1, glueAAAA, opt, glueA2})
+	}
+
+	tests := []struct {
+		name      string
+		want      *DNSPacket
+		truncated bool
+	}{
+		{"fits", full(), false},
+		{"last additional RRset", build([]DnsRecord{answer}, []DnsRecord{ns}, []DnsRecord{glueAAAA, opt}), false},
+		{"all additional RRsets", build([]DnsRecord{answer}, []DnsRecord{ns}, []DnsRecord{opt}), false},
+		{"authority RRset", build([]DnsRecord{answer}, nil, []DnsRecord{opt}), false},
+		{"answer does not fit", build(nil, nil, []DnsRecord{opt}), true},
+	}
+
+	for _, tt := range tests {
+		limit := uint(len(wireBytes(t, tt.want)))
+		if tt.truncated {
+			// Anything short of the answer forces TC.
+			limit = uint(len(wireBytes(t, build([]DnsRecord{answer}, nil, []DnsRecord{opt})))) - 1
+		}
+
+		packet := full()
+		if err := packet.Truncate(limit); err != nil {
+			t.Fatalf("%s: Truncate(%d): %v", tt.name, limit, err)
+		}
+
+		if packet.Header.TruncatedMessage != tt.truncated {
+			t.Errorf("%s: TC = %v, want %v", tt.name, packet.Header.TruncatedMessage, tt.truncated)
+		}
+		if !reflect.DeepEqual(packet.Questions, tt.want.Questions) {
+			t.Errorf("%s: questions = %v, want %v", tt.name, packet.Questions, tt.want.Questions)
+		}
+		for _, section := range []struct {
+			name      string
+			got, want []DnsRecord
+		}{
+			{"answers", packet.Answers, tt.want.Answers},
+			{"authorities", packet.Authorities, tt.want.Authorities},
+			{"resources", packet.Resources, tt.want.Resources},
+		} {
+			if !reflect.DeepEqual(section.got, section.want) {
+				t.Errorf("%s: %s = %v, want %v", tt.name, section.name, section.got, section.want)
+			}
+		}
+		if got := uint(len(wireBytes(t, packet))); got > limit {
+			t.Errorf("%s: truncated message is %d bytes, limit %d", tt.name, got, limit)
+		}
+	}
+
+	packet := build(nil, nil, nil)
+	if err := packet.Truncate(minQuestionSize); err == nil {
+		t.Errorf("Truncate below the question size: got no error")
+	}
+}
+
+func TestTruncateKeepsSignaturesWithTheirRRset(t *testing.T) {
+	sig := func(covered QueryType) RRSIGRecord {
+		return RRSIGRecord{Domain: "ns1.example.com", Class: ClassIN, TypeCovered: covered, Algorithm: 8, Labels: 3, OriginalTTL: 300, Expiration: 1735689600, Inception: 1704067200, KeyTag: 60485, SignerName: "example.com", Signature: make([]byte, 64), TTL: 300}
+	}
+	glueA := ARecord{Domain: "ns1.example.com", Class: ClassIN, Addr: net.IP{192, 0, 2, 53}, TTL: 300}
+	glueAAAA := AAAARecord{Domain: "ns1.example.com", Class: ClassIN, Addr: net.ParseIP("2001:db8::53"), TTL: 300}
+
+	packet := NewDNSPacket()
+	packet.Questions = append(packet.Questions, NewDNSQuestion("example.com", NS))
+	// The A signature is the last record, so the A RRset is dropped first
+	// and takes its signature along; the AAAA RRset keeps its own.
+	packet.Resources = []DnsRecord{glueA, sig(AAAA), glueAAAA, sig(A)}
+
+	want := []DnsRecord{sig(AAAA), glueAAAA}
+	expected := NewDNSPacket()
+	expected.Questions = packet.Questions
+	expected.Resources = want
+
+	if err := packet.Truncate(uint(len(wireBytes(t, expected)))); err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+	if !reflect.DeepEqual(packet.Resources, want) {
+		t.Errorf("resources = %v, want %v", packet.Resources, want)
+	}
+}